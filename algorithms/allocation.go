@@ -0,0 +1,64 @@
+package algorithms
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+)
+
+var ErrNoWeight = errors.New("weights must contain at least one positive value")
+
+// Allocate splits a non-negative total into parts proportional to weights,
+// guaranteeing the parts sum to exactly total.
+//
+// Algorithm (largest remainder / Hamilton method):
+//  1. Give each part floor(total × weight / Σweights).
+//  2. Hand the leftover units, one each, to the parts with the largest
+//     fractional remainders.
+//  3. Ties go to the earlier index, so the result is deterministic.
+//
+// Intermediate products use math/big, so large amounts cannot overflow.
+func Allocate(total int64, weights []int64) ([]int64, error) {
+	if total < 0 {
+		return nil, errors.New("total must not be negative")
+	}
+
+	sum := new(big.Int)
+	for _, w := range weights {
+		if w < 0 {
+			return nil, errors.New("weights must not be negative")
+		}
+		sum.Add(sum, big.NewInt(w))
+	}
+	if sum.Sign() == 0 {
+		return nil, ErrNoWeight
+	}
+
+	parts := make([]int64, len(weights))
+	remainders := make([]*big.Int, len(weights))
+	bigTotal := big.NewInt(total)
+	var allocated int64
+
+	for i, w := range weights {
+		product := new(big.Int).Mul(bigTotal, big.NewInt(w))
+		quotient, remainder := new(big.Int).QuoRem(product, sum, new(big.Int))
+		parts[i] = quotient.Int64() // ≤ total, so always fits
+		remainders[i] = remainder
+		allocated += parts[i]
+	}
+
+	order := make([]int, len(weights))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return remainders[order[a]].Cmp(remainders[order[b]]) > 0
+	})
+
+	// leftover < len(weights): each floor loses less than one unit
+	for k := int64(0); k < total-allocated; k++ {
+		parts[order[k]]++
+	}
+
+	return parts, nil
+}
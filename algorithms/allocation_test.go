@@ -0,0 +1,96 @@
+package algorithms
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   int64
+		weights []int64
+		want    []int64
+	}{
+		{"equal, remainder to the first", 10000, []int64{1, 1, 1}, []int64{3334, 3333, 3333}},
+		{"two leftover units", 10001, []int64{1, 1, 1}, []int64{3334, 3334, 3333}},
+		{"2:1 shares", 10000, []int64{2, 1}, []int64{6667, 3333}},
+		{"basis points", 10000, []int64{3333, 3333, 3334}, []int64{3333, 3333, 3334}},
+		{"largest remainder wins over index", 100, []int64{1, 2}, []int64{33, 67}},
+		{"tie goes to the earlier index", 101, []int64{50, 50}, []int64{51, 50}},
+		{"zero weight gets nothing", 500, []int64{0, 1, 0}, []int64{0, 500, 0}},
+		{"zero total", 0, []int64{1, 2, 3}, []int64{0, 0, 0}},
+		{"single part", 12345, []int64{7}, []int64{12345}},
+		{"no overflow on large products", math.MaxInt64, []int64{math.MaxInt64, math.MaxInt64},
+			[]int64{math.MaxInt64/2 + 1, math.MaxInt64 / 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Allocate(tt.total, tt.weights)
+			if err != nil {
+				t.Fatalf("Allocate(%d, %v): %v", tt.total, tt.weights, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Allocate(%d, %v) = %v, want %v", tt.total, tt.weights, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllocateErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   int64
+		weights []int64
+	}{
+		{"no weights", 100, nil},
+		{"all zero", 100, []int64{0, 0}},
+		{"negative weight", 100, []int64{3, -1}},
+		{"negative total", -100, []int64{1, 1}},
+		{"negative total, one part", -1, []int64{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := Allocate(tt.total, tt.weights); err == nil {
+				t.Errorf("Allocate(%d, %v) = %v, want an error", tt.total, tt.weights, got)
+			}
+		})
+	}
+}
+
+// The parts must always sum to the total, and each must be within one unit
+// of its exact proportional share.
+func TestAllocateConservesTotal(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		total := rng.Int63n(1_000_000_000)
+		weights := make([]int64, 1+rng.Intn(12))
+		var sum int64
+		for j := range weights {
+			weights[j] = rng.Int63n(10_000)
+			sum += weights[j]
+		}
+		if sum == 0 {
+			weights[0], sum = 1, 1
+		}
+
+		parts, err := Allocate(total, weights)
+		if err != nil {
+			t.Fatalf("Allocate(%d, %v): %v", total, weights, err)
+		}
+
+		var allocated int64
+		for j, p := range parts {
+			allocated += p
+			exact := float64(total) * float64(weights[j]) / float64(sum)
+			if math.Abs(float64(p)-exact) >= 1.000001 {
+				t.Fatalf("Allocate(%d, %v)[%d] = %d, exact share %.2f", total, weights, j, p, exact)
+			}
+		}
+		if allocated != total {
+			t.Fatalf("Allocate(%d, %v) sums to %d", total, weights, allocated)
+		}
+	}
+}
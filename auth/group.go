@@ -0,0 +1,160 @@
+package auth
+
+import (
+	"net/http"
+	"splitwise-api/config"
+	"splitwise-api/models"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// membershipKey is the gin context key holding the caller's GroupMember row
+// for the group the current route is scoped to.
+const membershipKey = "auth.membership"
+
+// RequireGroupMember guards /groups/:id/* routes: the caller must be a member
+// of group :id. Non-members get 403 whether or not the group exists, so group
+// IDs cannot be probed.
+func RequireGroupMember() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		groupID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+			return
+		}
+
+		if !loadMembership(c, uint(groupID)) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireExpenseGroupMember guards /expenses/:id/* routes by resolving the
+// expense's group and requiring the caller to be a member of it. A missing
+// expense gets the same 403 as a non-member, so expense IDs cannot be probed.
+func RequireExpenseGroupMember() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		expenseID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid expense ID"})
+			return
+		}
+
+		var expense models.Expense
+		if err := config.DB.First(&expense, expenseID).Error; err != nil {
+			abortNotMember(c)
+			return
+		}
+
+		if !loadMembership(c, expense.GroupID) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireDeletedExpenseGroupMember is RequireExpenseGroupMember for routes
+// acting on the trash: expense :id must be soft-deleted. Only members learn
+// that an expense exists but is not in the trash.
+func RequireDeletedExpenseGroupMember() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		expenseID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid expense ID"})
+			return
+		}
+
+		var expense models.Expense
+		if err := config.DB.Unscoped().First(&expense, expenseID).Error; err != nil {
+			abortNotMember(c)
+			return
+		}
+
+		if !loadMembership(c, expense.GroupID) {
+			return
+		}
+		if !expense.DeletedAt.Valid {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Expense not found in trash"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequirePaymentGroupMember guards /payments/:id routes by resolving the
+// payment's group and requiring the caller to be a member of it. A missing
+// payment gets the same 403 as a non-member.
+func RequirePaymentGroupMember() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		paymentID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid payment ID"})
+			return
+		}
+
+		var payment models.Payment
+		if err := config.DB.First(&payment, paymentID).Error; err != nil {
+			abortNotMember(c)
+			return
+		}
+
+		if !loadMembership(c, payment.GroupID) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireRecurringExpenseGroupMember guards /recurring-expenses/:id routes by
+// resolving the template's group and requiring the caller to be a member of
+// it. A missing template gets the same 403 as a non-member.
+func RequireRecurringExpenseGroupMember() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		recurringID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid recurring expense ID"})
+			return
+		}
+
+		var recurring models.RecurringExpense
+		if err := config.DB.First(&recurring, recurringID).Error; err != nil {
+			abortNotMember(c)
+			return
+		}
+
+		if !loadMembership(c, recurring.GroupID) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// CurrentMembership returns the caller's membership in the group the route
+// is scoped to. It must only be called from handlers behind one of the
+// Require*GroupMember middlewares.
+func CurrentMembership(c *gin.Context) models.GroupMember {
+	member, _ := c.MustGet(membershipKey).(models.GroupMember)
+	return member
+}
+
+// loadMembership stores the caller's membership of groupID in the context,
+// or aborts with 403 and returns false if there is none.
+func loadMembership(c *gin.Context, groupID uint) bool {
+	var member models.GroupMember
+	if err := config.DB.Where("group_id = ? AND user_id = ?", groupID, CurrentUserID(c)).First(&member).Error; err != nil {
+		abortNotMember(c)
+		return false
+	}
+
+	c.Set(membershipKey, member)
+	return true
+}
+
+// abortNotMember aborts with the 403 sent to non-members. Routes on a record
+// that does not exist send it too, so a missing ID and someone else's look
+// the same.
+func abortNotMember(c *gin.Context) {
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "You are not a member of this group"})
+}
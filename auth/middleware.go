@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"net/http"
+	"splitwise-api/config"
+	"splitwise-api/models"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Context keys holding the authenticated user's ID and session ID.
+const (
+	userIDKey    = "auth.userID"
+	sessionIDKey = "auth.sessionID"
+)
+
+// RequireAuth rejects requests without a valid "Authorization: Bearer <token>"
+// header and stores the caller's user ID in the context for handlers.
+func RequireAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		token, found := strings.CutPrefix(header, "Bearer ")
+		if !found || token == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing bearer token"})
+			return
+		}
+
+		claims, err := ParseAccessToken(token)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		userID, err := claims.UserID()
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		// The session may have been revoked (logout, stolen device) since
+		// the token was issued — that must take effect immediately.
+		var session models.Session
+		if err := config.DB.First(&session, claims.SessionID).Error; err != nil ||
+			session.UserID != userID || !session.Active(time.Now()) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Session has been revoked or has expired"})
+			return
+		}
+
+		// The user may have been removed since the token was issued
+		var user models.User
+		if err := config.DB.First(&user, userID).Error; err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User no longer exists"})
+			return
+		}
+
+		c.Set(userIDKey, userID)
+		c.Set(sessionIDKey, session.ID)
+		c.Next()
+	}
+}
+
+// CurrentUserID returns the authenticated caller's ID.
+// It must only be called from handlers behind RequireAuth.
+func CurrentUserID(c *gin.Context) uint {
+	return c.GetUint(userIDKey)
+}
+
+// CurrentSessionID returns the session the caller's access token is bound to.
+// It must only be called from handlers behind RequireAuth.
+func CurrentSessionID(c *gin.Context) uint {
+	return c.GetUint(sessionIDKey)
+}
@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"net/http"
+	"splitwise-api/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Permission is an action a group member may be allowed to take.
+type Permission string
+
+const (
+	PermAddExpense        Permission = "add_expense"
+	PermModifyOwnExpense  Permission = "modify_own_expense"
+	PermModifyAnyExpense  Permission = "modify_any_expense"
+	PermRecordPayment     Permission = "record_payment"
+	PermAddCategory       Permission = "add_category"
+	PermComment           Permission = "comment"
+	PermAddMember         Permission = "add_member"
+	PermRemoveMember      Permission = "remove_member"
+	PermRenameGroup       Permission = "rename_group"
+	PermExchangeRates     Permission = "manage_exchange_rates"
+	PermDeleteGroup       Permission = "delete_group"
+	PermManageRoles       Permission = "manage_roles"
+	PermTransferOwnership Permission = "transfer_ownership"
+)
+
+// rolePermissions is the permission matrix. Reading group data needs no entry:
+// every member, including viewers, may do that.
+//
+//	                  owner  admin  member  viewer
+//	add expense         ✓      ✓      ✓
+//	record payment      ✓      ✓      ✓
+//	add category        ✓      ✓      ✓
+//	comment             ✓      ✓      ✓
+//	edit/delete own     ✓      ✓      ✓
+//	edit/delete any     ✓      ✓
+//	add members         ✓      ✓
+//	remove members      ✓      ✓
+//	rename group        ✓      ✓
+//	exchange rates      ✓      ✓
+//	delete group        ✓
+//	change roles        ✓
+//	transfer owner      ✓
+//
+// "Own" and "any" cover both expenses and recorded payments. Comments are
+// deleted by their author (with PermComment) or by anyone holding
+// PermModifyAnyExpense. Any member may leave a group; removing someone else
+// needs PermRemoveMember, and PermManageRoles when that someone is an admin.
+var rolePermissions = map[string][]Permission{
+	models.RoleOwner: {
+		PermAddExpense, PermRecordPayment, PermAddCategory, PermComment, PermModifyOwnExpense, PermModifyAnyExpense,
+		PermAddMember, PermRemoveMember, PermRenameGroup, PermExchangeRates, PermDeleteGroup,
+		PermManageRoles, PermTransferOwnership,
+	},
+	models.RoleAdmin: {
+		PermAddExpense, PermRecordPayment, PermAddCategory, PermComment, PermModifyOwnExpense, PermModifyAnyExpense,
+		PermAddMember, PermRemoveMember, PermRenameGroup, PermExchangeRates,
+	},
+	models.RoleMember: {
+		PermAddExpense, PermRecordPayment, PermAddCategory, PermComment, PermModifyOwnExpense,
+	},
+	models.RoleViewer: {},
+}
+
+// Can reports whether a member with the given role holds permission p.
+func Can(role string, p Permission) bool {
+	for _, granted := range rolePermissions[role] {
+		if granted == p {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidRole reports whether role is one of the known membership roles.
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// RequirePermission aborts with 403 unless the caller's role in the current
+// group grants p. It must be chained after RequireGroupMember.
+func RequirePermission(p Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		member := CurrentMembership(c)
+		if !Can(member.Role, p) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":    "Your role in this group does not allow this action",
+				"role":     member.Role,
+				"required": p,
+			})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"splitwise-api/config"
+	"splitwise-api/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefreshTokenTTL is how long a session survives without being refreshed.
+// Every refresh slides the expiry forward, so active devices stay signed in.
+const RefreshTokenTTL = 30 * 24 * time.Hour
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token was already used; the session has been revoked")
+)
+
+// StartSession records a new signed-in device and returns it together with
+// the plaintext refresh token, which is shown to the client exactly once.
+func StartSession(userID uint, userAgent, ipAddress string) (models.Session, string, error) {
+	refreshToken, err := newRefreshToken()
+	if err != nil {
+		return models.Session{}, "", err
+	}
+
+	now := time.Now()
+	session := models.Session{
+		UserID:           userID,
+		RefreshTokenHash: hashToken(refreshToken),
+		UserAgent:        userAgent,
+		IPAddress:        ipAddress,
+		ExpiresAt:        now.Add(RefreshTokenTTL),
+		LastUsedAt:       now,
+	}
+	if err := config.DB.Create(&session).Error; err != nil {
+		return models.Session{}, "", err
+	}
+
+	return session, refreshToken, nil
+}
+
+// RotateSession exchanges a refresh token for a new one. The old token stops
+// working immediately, so a leaked token is only usable until the real
+// client refreshes again. Presenting a token that was already rotated out,
+// including by a concurrent refresh, revokes the whole session: either the
+// client or someone holding a copy of its token is replaying it.
+func RotateSession(refreshToken string) (models.Session, string, error) {
+	oldHash := hashToken(refreshToken)
+
+	var session models.Session
+	if err := config.DB.Where("refresh_token_hash = ?", oldHash).First(&session).Error; err != nil {
+		var retired models.RetiredRefreshToken
+		if config.DB.Where("token_hash = ?", oldHash).First(&retired).Error == nil {
+			if err := RevokeSession(retired.SessionID); err != nil {
+				return session, "", err
+			}
+			return session, "", ErrRefreshTokenReused
+		}
+		return session, "", ErrInvalidRefreshToken
+	}
+
+	now := time.Now()
+	if !session.Active(now) {
+		return session, "", ErrInvalidRefreshToken
+	}
+
+	newToken, err := newRefreshToken()
+	if err != nil {
+		return session, "", err
+	}
+
+	// Compare-and-swap on the old hash: of two refreshes racing with the same
+	// token only one updates the row.
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Session{}).
+			Where("id = ? AND refresh_token_hash = ? AND revoked_at IS NULL", session.ID, oldHash).
+			Updates(map[string]interface{}{
+				"refresh_token_hash": hashToken(newToken),
+				"expires_at":         now.Add(RefreshTokenTTL),
+				"last_used_at":       now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return ErrRefreshTokenReused
+		}
+		return tx.Create(&models.RetiredRefreshToken{SessionID: session.ID, TokenHash: oldHash}).Error
+	})
+	if errors.Is(err, ErrRefreshTokenReused) {
+		if err := RevokeSession(session.ID); err != nil {
+			return session, "", err
+		}
+		return session, "", ErrRefreshTokenReused
+	}
+	if err != nil {
+		return session, "", err
+	}
+
+	session.RefreshTokenHash = hashToken(newToken)
+	session.ExpiresAt = now.Add(RefreshTokenTTL)
+	session.LastUsedAt = now
+	return session, newToken, nil
+}
+
+// RevokeSession marks a single session as revoked. Access tokens bound to it
+// are rejected by RequireAuth from the next request on.
+func RevokeSession(sessionID uint) error {
+	return config.DB.Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", sessionID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeAllSessions revokes every active session of a user ("log out all devices").
+func RevokeAllSessions(userID uint) (int64, error) {
+	result := config.DB.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+// newRefreshToken returns 256 bits of randomness, base64url-encoded.
+func newRefreshToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
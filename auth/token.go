@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AccessTokenTTL is how long an issued access token stays valid.
+// Kept short: clients renew it with their refresh token.
+const AccessTokenTTL = 15 * time.Minute
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrExpiredToken = errors.New("token has expired")
+)
+
+// Claims is the payload carried inside a signed access token.
+type Claims struct {
+	Subject   string `json:"sub"` // user ID
+	SessionID uint   `json:"sid"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// UserID returns the numeric user ID stored in the subject claim.
+func (c Claims) UserID() (uint, error) {
+	id, err := strconv.ParseUint(c.Subject, 10, 64)
+	if err != nil || id == 0 {
+		return 0, ErrInvalidToken
+	}
+	return uint(id), nil
+}
+
+var (
+	keyOnce    sync.Once
+	signingKey []byte
+)
+
+// key returns the HMAC signing key. It is read from JWT_SECRET; when unset a
+// random key is generated, which means tokens do not survive a restart.
+func key() []byte {
+	keyOnce.Do(func() {
+		if secret := os.Getenv("JWT_SECRET"); secret != "" {
+			signingKey = []byte(secret)
+			return
+		}
+		signingKey = make([]byte, 32)
+		if _, err := rand.Read(signingKey); err != nil {
+			log.Fatal("Failed to generate JWT signing key!")
+		}
+		log.Println("JWT_SECRET not set — using a random signing key (tokens reset on restart)")
+	})
+	return signingKey
+}
+
+// jwtHeader is the fixed, pre-encoded header for HS256 tokens.
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// IssueAccessToken returns a signed HS256 JWT for the given user and session.
+//
+// The token format is standard: base64url(header).base64url(claims).signature,
+// so any JWT library can decode it. Only HS256 is accepted when parsing.
+func IssueAccessToken(userID, sessionID uint) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(AccessTokenTTL)
+	claims := Claims{
+		Subject:   strconv.FormatUint(uint64(userID), 10),
+		SessionID: sessionID,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + sign(unsigned), expiresAt, nil
+}
+
+// ParseAccessToken verifies the signature and expiry of a token and returns
+// its claims.
+func ParseAccessToken(token string) (Claims, error) {
+	var claims Claims
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != jwtHeader {
+		return claims, ErrInvalidToken
+	}
+
+	// Constant-time comparison so the signature cannot be guessed byte by byte
+	expected := sign(parts[0] + "." + parts[1])
+	if !hmac.Equal([]byte(parts[2]), []byte(expected)) {
+		return claims, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return claims, ErrInvalidToken
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return claims, ErrInvalidToken
+	}
+
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return claims, ErrExpiredToken
+	}
+
+	return claims, nil
+}
+
+func sign(unsigned string) string {
+	mac := hmac.New(sha256.New, key())
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
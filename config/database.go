@@ -3,6 +3,7 @@ package config
 import (
 	"log"
 	"splitwise-api/models"
+	"splitwise-api/search"
 
 	"github.com/glebarez/sqlite"
 	"gorm.io/gorm"
@@ -21,11 +22,110 @@ func ConnectDatabase() {
 	// Auto-migrate all models
 	database.AutoMigrate(
 		&models.User{},
+		&models.Session{},
+		&models.RetiredRefreshToken{},
 		&models.Group{},
 		&models.GroupMember{},
 		&models.Expense{},
 		&models.ExpenseSplit{},
+		&models.ExpensePayment{},
+		&models.ExpenseItem{},
+		&models.ExpenseItemParticipant{},
+		&models.ExpenseRevision{},
+		&models.ExpenseComment{},
+		&models.ExpenseAttachment{},
+		&models.Activity{},
+		&models.RecurringExpense{},
+		&models.Category{},
+		&models.Payment{},
+		&models.ExchangeRate{},
 	)
 
+	backfillGroupOwners(database)
+	seedCategories(database)
+	backfillExpenseDates(database)
+	backfillExpenseRates(database)
+	purgeSplitlessExpenses(database)
+	search.Setup(database)
+
 	log.Println("Database connected & migrated successfully 🚀")
 }
+
+// backfillGroupOwners makes each group's creator its owner for groups created
+// before membership roles existed. Groups that already have an owner (e.g.
+// after an ownership transfer) are left untouched.
+func backfillGroupOwners(db *gorm.DB) {
+	db.Exec(`
+		UPDATE group_members SET role = ?
+		WHERE deleted_at IS NULL
+		  AND user_id = (SELECT created_by FROM groups WHERE groups.id = group_members.group_id)
+		  AND NOT EXISTS (
+		      SELECT 1 FROM group_members owners
+		      WHERE owners.group_id = group_members.group_id
+		        AND owners.role = ? AND owners.deleted_at IS NULL)`,
+		models.RoleOwner, models.RoleOwner)
+}
+
+// seedCategories creates any built-in category that does not exist yet, so
+// new defaults appear on the next start without touching existing rows.
+func seedCategories(db *gorm.DB) {
+	for _, name := range models.DefaultCategories {
+		var category models.Category
+		db.Where(models.Category{Name: name}).Where("group_id IS NULL").FirstOrCreate(&category)
+	}
+}
+
+// backfillExpenseDates dates expenses recorded before expense_date existed
+// on the day they were created.
+func backfillExpenseDates(db *gorm.DB) {
+	var expenses []models.Expense
+	db.Unscoped().Select("id", "created_at").Where("expense_date IS NULL").Find(&expenses)
+	for _, e := range expenses {
+		db.Unscoped().Model(&models.Expense{}).Where("id = ?", e.ID).
+			UpdateColumn("expense_date", e.CreatedAt.UTC())
+	}
+}
+
+// backfillExpenseRates fixes a rate on foreign-currency expenses recorded
+// before expenses kept their own: the newest rate stored for the pair, or the
+// inverse of the newest one for the opposite pair. Rates from that time have
+// no group. Expenses without any rate are left for an edit to fix.
+func backfillExpenseRates(db *gorm.DB) {
+	var expenses []struct {
+		ID            uint
+		Currency      string
+		GroupCurrency string
+	}
+	db.Raw(`
+		SELECT expenses.id, expenses.currency, groups.currency AS group_currency
+		FROM expenses JOIN groups ON groups.id = expenses.group_id
+		WHERE expenses.rate_den = 0 AND expenses.currency <> groups.currency`).
+		Scan(&expenses)
+
+	for _, e := range expenses {
+		var rate models.ExchangeRate
+		num, den := int64(0), int64(0)
+		if db.Where("group_id IS NULL AND from_currency = ? AND to_currency = ?", e.Currency, e.GroupCurrency).
+			Order("updated_at DESC, id DESC").First(&rate).Error == nil {
+			num, den = rate.RateNum, rate.RateDen
+		} else if db.Where("group_id IS NULL AND from_currency = ? AND to_currency = ?", e.GroupCurrency, e.Currency).
+			Order("updated_at DESC, id DESC").First(&rate).Error == nil {
+			num, den = rate.RateDen, rate.RateNum
+		} else {
+			continue
+		}
+		db.Unscoped().Model(&models.Expense{}).Where("id = ?", e.ID).
+			UpdateColumns(map[string]interface{}{"rate_num": num, "rate_den": den})
+	}
+}
+
+// purgeSplitlessExpenses removes deleted expenses that never had splits.
+// Before expense creation was transactional, a request that failed
+// validation left its expense row behind soft-deleted; restored from the
+// trash, it would credit the payer with nobody owing anything.
+func purgeSplitlessExpenses(db *gorm.DB) {
+	db.Exec(`
+		DELETE FROM expenses
+		WHERE deleted_at IS NOT NULL
+		  AND NOT EXISTS (SELECT 1 FROM expense_splits WHERE expense_splits.expense_id = expenses.id)`)
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"splitwise-api/models"
+	"splitwise-api/money"
+	"strings"
+)
+
+// LoadExchangeRates imports rates from the CSV file named by the
+// EXCHANGE_RATES_FILE env var, one "FROM,TO,RATE" per line (e.g.
+// "USD,INR,83.25"). Lines starting with # are comments.
+//
+// A pair already imported from a file is only updated when its rate changed,
+// so restarting the server does not override newer rates entered by users.
+func LoadExchangeRates() {
+	path := os.Getenv("EXCHANGE_RATES_FILE")
+	if path == "" {
+		return
+	}
+
+	loaded, err := loadExchangeRatesFile(path)
+	if err != nil {
+		log.Fatal("Failed to load exchange rates: ", err)
+	}
+
+	log.Printf("Loaded %d exchange rates from %s", loaded, path)
+}
+
+func loadExchangeRatesFile(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+
+	loaded := 0
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return loaded, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		from, okFrom := money.NormalizeCurrency(record[0])
+		to, okTo := money.NormalizeCurrency(record[1])
+		if !okFrom || !okTo || from == to {
+			return loaded, fmt.Errorf("line %d: invalid currency pair %q → %q", line, record[0], record[1])
+		}
+		rateText := strings.TrimSpace(record[2])
+		num, den, err := money.ParseRate(rateText)
+		if err != nil {
+			return loaded, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		var rate models.ExchangeRate
+		found := DB.Where("from_currency = ? AND to_currency = ? AND source = ?", from, to, "file").
+			First(&rate).Error == nil
+		if found && rate.RateNum == num && rate.RateDen == den {
+			continue
+		}
+
+		rate.FromCurrency, rate.ToCurrency, rate.Source = from, to, "file"
+		rate.Rate, rate.RateNum, rate.RateDen = rateText, num, den
+		if err := DB.Save(&rate).Error; err != nil {
+			return loaded, err
+		}
+		loaded++
+	}
+
+	return loaded, nil
+}
@@ -0,0 +1,25 @@
+package config
+
+import (
+	"log"
+	"os"
+	"splitwise-api/storage"
+)
+
+// Files stores expense attachments.
+var Files storage.Store
+
+// ConnectStorage opens the attachment store: a local directory named by the
+// ATTACHMENTS_DIR env var, "uploads" next to the database by default.
+func ConnectStorage() {
+	dir := os.Getenv("ATTACHMENTS_DIR")
+	if dir == "" {
+		dir = "uploads"
+	}
+
+	store, err := storage.NewLocal(dir)
+	if err != nil {
+		log.Fatal("Failed to open attachment storage: ", err)
+	}
+	Files = store
+}
@@ -0,0 +1,28 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// TrashRetention is how long a deleted expense stays in the trash, and can
+// be restored, before the purge job removes it for good. Zero keeps deleted
+// expenses forever.
+var TrashRetention = 30 * 24 * time.Hour
+
+// LoadTrashRetention reads TRASH_RETENTION_DAYS (default 30; 0 disables the
+// purge).
+func LoadTrashRetention() {
+	value := os.Getenv("TRASH_RETENTION_DAYS")
+	if value == "" {
+		return
+	}
+
+	days, err := strconv.Atoi(value)
+	if err != nil || days < 0 {
+		log.Fatal("TRASH_RETENTION_DAYS must be a whole number of days, 0 to keep the trash forever")
+	}
+	TrashRetention = time.Duration(days) * 24 * time.Hour
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"splitwise-api/auth"
+	"splitwise-api/config"
+	"splitwise-api/models"
+	"splitwise-api/money"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// activityCursor is the position of the last activity on a page. IDs grow
+// with time, so the ID alone orders the feed.
+type activityCursor struct {
+	ID uint `json:"id"`
+}
+
+// GetGroupActivity — GET /groups/:id/activity
+// The group's activity feed, newest first, paginated like GetExpenses
+// (?limit=, ?cursor=).
+func GetGroupActivity(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	listActivity(c, config.DB.Where("group_id = ?", groupID))
+}
+
+// GetUserActivity — GET /users/:id/activity
+// Activity across every group the user belongs to, newest first, paginated
+// like GetExpenses. Like the summary, it is only visible to the user.
+func GetUserActivity(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if uint(userID) != auth.CurrentUserID(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only view your own activity"})
+		return
+	}
+
+	groupIDs := config.DB.Model(&models.GroupMember{}).Select("group_id").Where("user_id = ?", userID)
+	listActivity(c, config.DB.Where("group_id IN (?)", groupIDs))
+}
+
+// listActivity writes one page of the activities selected by query.
+func listActivity(c *gin.Context, query *gorm.DB) {
+	limit, err := pageLimit(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if token := c.Query("cursor"); token != "" {
+		var cursor activityCursor
+		if err := decodeCursor(token, &cursor); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		query = query.Where("id < ?", cursor.ID)
+	}
+
+	// One extra row tells whether another page follows
+	var activities []models.Activity
+	query.Order("id DESC").Limit(limit + 1).Find(&activities)
+
+	hasMore := len(activities) > limit
+	nextCursor := ""
+	if hasMore {
+		activities = activities[:limit]
+		nextCursor = encodeCursor(activityCursor{ID: activities[limit-1].ID})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"activities": activities,
+		"page":       pageEnvelope(limit, hasMore, nextCursor),
+	})
+}
+
+// recordActivity adds an activity in tx, the transaction making the change
+// it describes, so the feed never shows a change that was rolled back.
+func recordActivity(tx *gorm.DB, activity models.Activity, details gin.H) error {
+	if details != nil {
+		data, err := json.Marshal(details)
+		if err != nil {
+			return err
+		}
+		activity.Details = data
+	}
+	return tx.Create(&activity).Error
+}
+
+// recordExpenseActivity records a change to expense e, described by its
+// description and amount as they are now, plus any extra details.
+func recordExpenseActivity(tx *gorm.DB, kind string, actorID uint, e models.Expense, extra gin.H) error {
+	details := gin.H{
+		"description":      e.Description,
+		"amount":           e.Amount,
+		"currency":         e.Currency,
+		"amount_formatted": money.Money{Amount: e.Amount, Currency: e.Currency}.String(),
+	}
+	for k, v := range extra {
+		details[k] = v
+	}
+	return recordActivity(tx, models.Activity{
+		GroupID:   e.GroupID,
+		ActorID:   actorID,
+		Type:      kind,
+		ExpenseID: idRef(e.ID),
+	}, details)
+}
+
+// idRef returns a pointer to a copy of id, for the optional ID columns.
+func idRef(id uint) *uint {
+	return &id
+}
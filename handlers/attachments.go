@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"splitwise-api/auth"
+	"splitwise-api/config"
+	"splitwise-api/models"
+	"splitwise-api/storage"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxAttachmentSize caps an uploaded file, in bytes.
+const maxAttachmentSize = 10 << 20 // 10 MiB
+
+// attachmentTypes are the accepted content types, as sniffed by
+// http.DetectContentType, with the extension used for stored files.
+var attachmentTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+}
+
+// AddAttachment — POST /expenses/:id/attachments
+// Uploads a receipt image (JPEG, PNG, GIF, WebP) or PDF as multipart form
+// field "file", at most 10 MiB. The type is taken from the file's content,
+// never from its name or the client's header. Images get a thumbnail.
+func AddAttachment(c *gin.Context) {
+	expenseID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid expense ID"})
+		return
+	}
+
+	// Leave room for the multipart framing around the file itself
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxAttachmentSize+1<<20)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File must be at most 10 MiB"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Send the file as multipart form field \"file\""})
+		return
+	}
+	if header.Size > maxAttachmentSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File must be at most 10 MiB"})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not read the uploaded file"})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxAttachmentSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not read the uploaded file"})
+		return
+	}
+	if len(data) > maxAttachmentSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File must be at most 10 MiB"})
+		return
+	}
+	if len(data) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File is empty"})
+		return
+	}
+
+	contentType := http.DetectContentType(data)
+	ext, ok := attachmentTypes[contentType]
+	if !ok {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"error":        "Only JPEG, PNG, GIF, WebP images and PDF files are accepted",
+			"content_type": contentType,
+		})
+		return
+	}
+
+	// Random keys: nothing about the stored path comes from the client
+	key := fmt.Sprintf("expenses/%d/%s", expenseID, randomKey())
+	attachment := models.ExpenseAttachment{
+		ExpenseID:   uint(expenseID),
+		UploadedBy:  auth.CurrentUserID(c),
+		FileName:    attachmentFileName(header.Filename, ext),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		StorageKey:  key + ext,
+	}
+
+	if err := config.Files.Put(attachment.StorageKey, bytes.NewReader(data)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store file"})
+		return
+	}
+	if thumb, ok := makeThumbnail(data); ok {
+		attachment.ThumbnailKey = key + "_thumb.jpg"
+		if err := config.Files.Put(attachment.ThumbnailKey, bytes.NewReader(thumb)); err != nil {
+			attachment.ThumbnailKey = "" // the original is still usable
+		}
+	}
+	attachment.HasThumbnail = attachment.ThumbnailKey != ""
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&attachment).Error; err != nil {
+			return err
+		}
+		return recordAttachmentActivity(tx, models.ActivityAttachmentAdded, attachment.UploadedBy, attachment)
+	})
+	if err != nil {
+		config.Files.Delete(attachment.StorageKey)
+		if attachment.HasThumbnail {
+			config.Files.Delete(attachment.ThumbnailKey)
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save attachment"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "Attachment uploaded successfully",
+		"attachment": attachment,
+	})
+}
+
+// GetAttachments — GET /expenses/:id/attachments
+// Lists an expense's attachments, oldest first.
+func GetAttachments(c *gin.Context) {
+	expenseID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid expense ID"})
+		return
+	}
+
+	var attachments []models.ExpenseAttachment
+	config.DB.Where("expense_id = ?", expenseID).Order("id ASC").Find(&attachments)
+
+	c.JSON(http.StatusOK, gin.H{
+		"expense_id":  expenseID,
+		"attachments": attachments,
+	})
+}
+
+// DownloadAttachment — GET /expenses/:id/attachments/:attachment_id
+// Serves the file itself. ?thumbnail=true serves the image thumbnail.
+func DownloadAttachment(c *gin.Context) {
+	attachment, ok := findAttachment(c)
+	if !ok {
+		return
+	}
+
+	key, contentType, fileName := attachment.StorageKey, attachment.ContentType, attachment.FileName
+	if c.Query("thumbnail") == "true" {
+		if !attachment.HasThumbnail {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Attachment has no thumbnail"})
+			return
+		}
+		key, contentType = attachment.ThumbnailKey, "image/jpeg"
+		fileName = strings.TrimSuffix(fileName, filepath.Ext(fileName)) + "_thumb.jpg"
+	}
+
+	file, err := config.Files.Open(key)
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file"})
+		return
+	}
+	defer file.Close()
+
+	size := int64(-1) // unknown for thumbnails
+	if key == attachment.StorageKey {
+		size = attachment.Size
+	}
+	c.DataFromReader(http.StatusOK, size, contentType, file, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("inline", map[string]string{"filename": fileName}),
+		"X-Content-Type-Options": "nosniff",
+		"Cache-Control":          "private, max-age=3600",
+	})
+}
+
+// DeleteAttachment — DELETE /expenses/:id/attachments/:attachment_id
+// Soft-deletes an attachment; the trash purge removes the row and its file
+// after the retention period. Uploaders may delete their own; members
+// allowed to modify any expense may delete anyone's.
+func DeleteAttachment(c *gin.Context) {
+	attachment, ok := findAttachment(c)
+	if !ok {
+		return
+	}
+
+	member := auth.CurrentMembership(c)
+	if !canModify(member, attachment.UploadedBy, auth.PermAddExpense) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only delete your own attachments"})
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&attachment).Error; err != nil {
+			return err
+		}
+		return recordAttachmentActivity(tx, models.ActivityAttachmentDeleted, member.UserID, attachment)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete attachment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Attachment deleted successfully"})
+}
+
+// recordAttachmentActivity records a change to an attachment, with the
+// expense it belongs to.
+func recordAttachmentActivity(tx *gorm.DB, kind string, actorID uint, a models.ExpenseAttachment) error {
+	var expense models.Expense
+	if err := tx.Session(&gorm.Session{NewDB: true}).First(&expense, a.ExpenseID).Error; err != nil {
+		return err
+	}
+	return recordExpenseActivity(tx, kind, actorID, expense,
+		gin.H{"attachment_id": a.ID, "file_name": a.FileName})
+}
+
+// findAttachment loads the :attachment_id of the :id expense. On failure it
+// has already written the error response and returns false.
+func findAttachment(c *gin.Context) (models.ExpenseAttachment, bool) {
+	var attachment models.ExpenseAttachment
+
+	expenseID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid expense ID"})
+		return attachment, false
+	}
+	attachmentID, err := strconv.Atoi(c.Param("attachment_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
+		return attachment, false
+	}
+
+	if err := config.DB.Where("expense_id = ?", expenseID).First(&attachment, attachmentID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+		return attachment, false
+	}
+	return attachment, true
+}
+
+// attachmentFileName cleans an uploaded file name for use in downloads and
+// makes its extension match the detected type.
+func attachmentFileName(name, ext string) string {
+	name = strings.TrimSpace(filepath.Base(strings.ReplaceAll(name, `\`, "/")))
+	if name == "." || name == "/" || name == "" {
+		name = "attachment"
+	}
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	if len(name) > 100 {
+		name = name[:100]
+	}
+	return strings.ToValidUTF8(name, "") + ext
+}
+
+// randomKey returns 16 random bytes as hex.
+func randomKey() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
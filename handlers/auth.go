@@ -2,8 +2,11 @@ package handlers
 
 import (
 	"net/http"
+	"splitwise-api/auth"
 	"splitwise-api/config"
 	"splitwise-api/models"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
@@ -51,6 +54,172 @@ func Register(c *gin.Context) {
 	})
 }
 
+// Login — POST /login
+// Verifies the bcrypt password hash, starts a new session and issues a
+// short-lived access token plus a long-lived refresh token.
+func Login(c *gin.Context) {
+	var input struct {
+		Email    string `json:"email" binding:"required,email"`
+		Password string `json:"password" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Same error for unknown email and wrong password — don't reveal which
+	var user models.User
+	if err := config.DB.Where("email = ?", input.Email).First(&user).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
+		return
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
+		return
+	}
+
+	session, refreshToken, err := auth.StartSession(user.ID, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start session"})
+		return
+	}
+
+	tokens, err := tokenResponse(session, refreshToken)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue token"})
+		return
+	}
+
+	tokens["message"] = "Login successful"
+	tokens["user"] = gin.H{
+		"id":    user.ID,
+		"name":  user.Name,
+		"email": user.Email,
+	}
+	c.JSON(http.StatusOK, tokens)
+}
+
+// RefreshToken — POST /token/refresh
+// Exchanges a refresh token for a new access token. The refresh token is
+// rotated: the one sent in is invalid after this call.
+func RefreshToken(c *gin.Context) {
+	var input struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, refreshToken, err := auth.RotateSession(input.RefreshToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := tokenResponse(session, refreshToken)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout — POST /logout
+// Revokes the session the caller's access token belongs to.
+func Logout(c *gin.Context) {
+	if err := auth.RevokeSession(auth.CurrentSessionID(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// LogoutAll — POST /logout/all
+// Revokes every session of the caller, including the current one.
+func LogoutAll(c *gin.Context) {
+	revoked, err := auth.RevokeAllSessions(auth.CurrentUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":          "Logged out of all devices",
+		"sessions_revoked": revoked,
+	})
+}
+
+// GetSessions — GET /sessions
+// Lists the caller's active sessions so a lost device can be identified.
+func GetSessions(c *gin.Context) {
+	var sessions []models.Session
+	config.DB.
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", auth.CurrentUserID(c), time.Now()).
+		Order("last_used_at DESC").
+		Find(&sessions)
+
+	currentID := auth.CurrentSessionID(c)
+	result := []gin.H{}
+	for _, s := range sessions {
+		result = append(result, gin.H{
+			"id":           s.ID,
+			"user_agent":   s.UserAgent,
+			"ip_address":   s.IPAddress,
+			"created_at":   s.CreatedAt,
+			"last_used_at": s.LastUsedAt,
+			"expires_at":   s.ExpiresAt,
+			"current":      s.ID == currentID,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": result})
+}
+
+// RevokeUserSession — DELETE /sessions/:id
+// Kills one of the caller's sessions, e.g. on a stolen phone.
+func RevokeUserSession(c *gin.Context) {
+	sessionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	// Only the owner may revoke a session; other users' IDs look like 404s
+	var session models.Session
+	if err := config.DB.Where("id = ? AND user_id = ?", sessionID, auth.CurrentUserID(c)).First(&session).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+
+	if err := auth.RevokeSession(session.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked successfully"})
+}
+
+// tokenResponse builds the token payload shared by login and refresh.
+func tokenResponse(session models.Session, refreshToken string) (gin.H, error) {
+	accessToken, expiresAt, err := auth.IssueAccessToken(session.UserID, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return gin.H{
+		"access_token":             accessToken,
+		"token_type":               "Bearer",
+		"expires_at":               expiresAt,
+		"refresh_token":            refreshToken,
+		"refresh_token_expires_at": session.ExpiresAt,
+	}, nil
+}
+
 // GetUsers returns all registered users (password excluded)
 func GetUsers(c *gin.Context) {
 	var users []models.User
@@ -66,4 +235,4 @@ func GetUsers(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{"users": result})
-}
\ No newline at end of file
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"splitwise-api/auth"
+	"splitwise-api/config"
+	"splitwise-api/models"
+	"splitwise-api/money"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetCategories — GET /groups/:id/categories
+// Lists the built-in categories followed by the group's own.
+func GetCategories(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	var categories []models.Category
+	config.DB.Where("group_id IS NULL OR group_id = ?", groupID).
+		Order("group_id IS NOT NULL, name").Find(&categories)
+
+	c.JSON(http.StatusOK, gin.H{"categories": categories})
+}
+
+// AddCategory — POST /groups/:id/categories
+// Adds a custom category to the group. Names are case-insensitive and must
+// not clash with a built-in category or another of the group's.
+func AddCategory(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	var input struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	name := strings.ToLower(strings.TrimSpace(input.Name))
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Category name cannot be empty"})
+		return
+	}
+
+	var existing models.Category
+	if err := config.DB.Where("name = ? AND (group_id IS NULL OR group_id = ?)", name, groupID).
+		First(&existing).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Category already exists", "category": existing})
+		return
+	}
+
+	category := models.Category{GroupID: idRef(uint(groupID)), Name: name}
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&category).Error; err != nil {
+			return err
+		}
+		return recordActivity(tx, models.Activity{
+			GroupID: uint(groupID),
+			ActorID: auth.CurrentUserID(c),
+			Type:    models.ActivityCategoryAdded,
+		}, gin.H{"category_id": category.ID, "name": category.Name})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create category"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Category added successfully",
+		"category": category,
+	})
+}
+
+// GetCategoryReport — GET /groups/:id/reports/categories
+// Spending per category, and per member within each category, in the group
+// currency. A member's spending is their share of each expense (what they
+// owe on it), not what they happened to pay. Largest categories come first.
+// ?from= and ?to= (and ?tz=) limit the expense dates, as for GetExpenses.
+func GetCategoryReport(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	var group models.Group
+	if err := config.DB.First(&group, groupID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+		return
+	}
+
+	query, err := filterExpenseDates(config.DB.Where("group_id = ?", groupID),
+		c.Query("from"), c.Query("to"), c.Query("tz"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var expenses []models.Expense
+	query.Preload("Splits").Preload("Payers").Find(&expenses)
+
+	// Per category (0 = uncategorized): total and each member's share
+	totals := make(map[uint]int64)
+	shares := make(map[uint]map[uint]int64)
+	for _, e := range expenses {
+		_, owed, err := convertExpense(e, group.Currency)
+		if err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+
+		var categoryID uint
+		if e.CategoryID != nil {
+			categoryID = *e.CategoryID
+		}
+		if shares[categoryID] == nil {
+			shares[categoryID] = make(map[uint]int64)
+		}
+		for i, s := range e.Splits {
+			if err := addBalance(totals, categoryID, owed[i]); err != nil {
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+				return
+			}
+			if err := addBalance(shares[categoryID], s.UserID, owed[i]); err != nil {
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+				return
+			}
+		}
+	}
+
+	formatted := func(amount int64) string {
+		return money.Money{Amount: amount, Currency: group.Currency}.String()
+	}
+
+	result := []gin.H{}
+	for _, categoryID := range sortedByAmount(totals) {
+		name := "uncategorized"
+		var id *uint // null for uncategorized
+		if categoryID != 0 {
+			var category models.Category
+			config.DB.Unscoped().First(&category, categoryID)
+			name, id = category.Name, &category.ID
+		}
+
+		members := []gin.H{}
+		for _, userID := range sortedByAmount(shares[categoryID]) {
+			var user models.User
+			config.DB.First(&user, userID)
+			members = append(members, gin.H{
+				"user_id":          userID,
+				"name":             user.Name,
+				"amount":           shares[categoryID][userID],
+				"amount_formatted": formatted(shares[categoryID][userID]),
+			})
+		}
+
+		result = append(result, gin.H{
+			"category_id":     id,
+			"name":            name,
+			"total":           totals[categoryID],
+			"total_formatted": formatted(totals[categoryID]),
+			"members":         members,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"group_id":   groupID,
+		"currency":   group.Currency,
+		"categories": result,
+	})
+}
+
+// categoryAvailable reports whether a category is built-in or belongs to the group.
+func categoryAvailable(groupID, categoryID uint) bool {
+	var category models.Category
+	return config.DB.Where("id = ? AND (group_id IS NULL OR group_id = ?)", categoryID, groupID).
+		First(&category).Error == nil
+}
+
+// categoryRef turns an input category ID into the nullable column value.
+func categoryRef(categoryID uint) *uint {
+	if categoryID == 0 {
+		return nil
+	}
+	return &categoryID
+}
+
+// sortedByAmount returns the keys of amounts, largest amount first
+// (ties by key, so the order is stable).
+func sortedByAmount(amounts map[uint]int64) []uint {
+	keys := make([]uint, 0, len(amounts))
+	for k := range amounts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		if amounts[keys[a]] != amounts[keys[b]] {
+			return amounts[keys[a]] > amounts[keys[b]]
+		}
+		return keys[a] < keys[b]
+	})
+	return keys
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"net/http"
+	"splitwise-api/auth"
+	"splitwise-api/config"
+	"splitwise-api/models"
+	"splitwise-api/search"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxCommentLength caps a comment body, in characters.
+const maxCommentLength = 2000
+
+// AddComment — POST /expenses/:id/comments
+// Adds a comment to the discussion thread of an expense.
+func AddComment(c *gin.Context) {
+	expenseID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid expense ID"})
+		return
+	}
+
+	var input struct {
+		Body string `json:"body" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	body := strings.TrimSpace(input.Body)
+	if body == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Comment cannot be empty"})
+		return
+	}
+	if utf8.RuneCountInString(body) > maxCommentLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Comment must be at most " + strconv.Itoa(maxCommentLength) + " characters"})
+		return
+	}
+
+	comment := models.ExpenseComment{
+		ExpenseID: uint(expenseID),
+		UserID:    auth.CurrentUserID(c),
+		Body:      body,
+	}
+
+	// The comment, its search entry and the activity are written together
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&comment).Error; err != nil {
+			return err
+		}
+		if err := search.IndexExpense(tx, comment.ExpenseID); err != nil {
+			return err
+		}
+		return recordCommentActivity(tx, models.ActivityCommentAdded, comment.UserID, comment)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add comment"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Comment added successfully",
+		"comment": comment,
+	})
+}
+
+// GetComments — GET /expenses/:id/comments
+// Lists the comments on an expense, oldest first.
+func GetComments(c *gin.Context) {
+	expenseID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid expense ID"})
+		return
+	}
+
+	var comments []models.ExpenseComment
+	config.DB.Where("expense_id = ?", expenseID).Order("created_at ASC, id ASC").Find(&comments)
+
+	c.JSON(http.StatusOK, gin.H{
+		"expense_id": expenseID,
+		"comments":   comments,
+	})
+}
+
+// DeleteComment — DELETE /expenses/:id/comments/:comment_id
+// Soft-deletes a comment. Authors may delete their own comments; members
+// allowed to modify any expense (owners and admins) may delete anyone's.
+func DeleteComment(c *gin.Context) {
+	expenseID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid expense ID"})
+		return
+	}
+	commentID, err := strconv.Atoi(c.Param("comment_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return
+	}
+
+	var comment models.ExpenseComment
+	if err := config.DB.Where("expense_id = ?", expenseID).First(&comment, commentID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+		return
+	}
+
+	member := auth.CurrentMembership(c)
+	if !canModify(member, comment.UserID, auth.PermComment) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only delete your own comments"})
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&comment).Error; err != nil {
+			return err
+		}
+		if err := search.IndexExpense(tx, comment.ExpenseID); err != nil {
+			return err
+		}
+		return recordCommentActivity(tx, models.ActivityCommentDeleted, member.UserID, comment)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete comment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted successfully"})
+}
+
+// recordCommentActivity records a change to a comment, with the expense it
+// is on. The text is only kept for new comments: a deleted comment should not
+// live on in the feed.
+func recordCommentActivity(tx *gorm.DB, kind string, actorID uint, comment models.ExpenseComment) error {
+	var expense models.Expense
+	if err := tx.Session(&gorm.Session{NewDB: true}).First(&expense, comment.ExpenseID).Error; err != nil {
+		return err
+	}
+
+	details := gin.H{"comment_id": comment.ID, "author_id": comment.UserID}
+	if kind == models.ActivityCommentAdded {
+		details["comment"] = comment.Body
+	}
+	return recordExpenseActivity(tx, kind, actorID, expense, details)
+}
+
+// commentCounts returns the number of live comments per expense.
+func commentCounts(expenseIDs []uint) map[uint]int64 {
+	var rows []struct {
+		ExpenseID uint
+		Count     int64
+	}
+	config.DB.Model(&models.ExpenseComment{}).
+		Select("expense_id, count(*) AS count").
+		Where("expense_id IN ?", expenseIDs).
+		Group("expense_id").
+		Scan(&rows)
+
+	counts := make(map[uint]int64, len(rows))
+	for _, r := range rows {
+		counts[r.ExpenseID] = r.Count
+	}
+	return counts
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"splitwise-api/auth"
+	"splitwise-api/config"
+	"splitwise-api/models"
+	"splitwise-api/money"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AddExchangeRate — POST /groups/:id/exchange-rates
+// Stores "1 from_currency = rate to_currency" for this group. Owners and
+// admins only: the rate decides what members owe each other. It applies to
+// expenses recorded or edited from now on; existing expenses keep the rate
+// they were recorded with.
+func AddExchangeRate(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	var input struct {
+		FromCurrency string `json:"from_currency" binding:"required"`
+		ToCurrency   string `json:"to_currency" binding:"required"`
+		Rate         string `json:"rate" binding:"required"` // decimal string, e.g. "83.25"
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	from, okFrom := money.NormalizeCurrency(input.FromCurrency)
+	to, okTo := money.NormalizeCurrency(input.ToCurrency)
+	if !okFrom || !okTo {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Currencies must be supported ISO 4217 codes"})
+		return
+	}
+	if from == to {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from_currency and to_currency must differ"})
+		return
+	}
+
+	num, den, err := money.ParseRate(input.Rate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	gid := uint(groupID)
+	rate := models.ExchangeRate{
+		GroupID:      &gid,
+		FromCurrency: from,
+		ToCurrency:   to,
+		Rate:         input.Rate,
+		RateNum:      num,
+		RateDen:      den,
+		Source:       "user",
+		CreatedBy:    auth.CurrentUserID(c),
+	}
+	if err := config.DB.Create(&rate).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save exchange rate"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":       "Exchange rate saved successfully",
+		"exchange_rate": rate,
+	})
+}
+
+// GetExchangeRates — GET /groups/:id/exchange-rates?from=USD&to=INR
+// Lists the rates the group can use, its own and the shared ones, newest
+// first. Both filters are optional.
+func GetExchangeRates(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	query := config.DB.Where("group_id = ? OR group_id IS NULL", groupID).Order("updated_at DESC, id DESC")
+	if from, ok := money.NormalizeCurrency(c.Query("from")); ok {
+		query = query.Where("from_currency = ?", from)
+	}
+	if to, ok := money.NormalizeCurrency(c.Query("to")); ok {
+		query = query.Where("to_currency = ?", to)
+	}
+
+	var rates []models.ExchangeRate
+	query.Find(&rates)
+
+	c.JSON(http.StatusOK, gin.H{"exchange_rates": rates})
+}
+
+// exchangeRate is a rate as an exact fraction: 1 unit = num/den units.
+type exchangeRate struct {
+	num, den int64
+}
+
+// findExchangeRate returns the rate from → to for a group: its own newest
+// rate for the pair, else the exact inverse of its own to → from rate, else
+// the same two lookups among the shared rates.
+func findExchangeRate(groupID uint, from, to string) (exchangeRate, error) {
+	scopes := []*gorm.DB{
+		config.DB.Where("group_id = ?", groupID),
+		config.DB.Where("group_id IS NULL"),
+	}
+	for _, scope := range scopes {
+		var stored models.ExchangeRate
+		if err := scope.Session(&gorm.Session{}).
+			Where("from_currency = ? AND to_currency = ?", from, to).
+			Order("updated_at DESC, id DESC").First(&stored).Error; err == nil {
+			return exchangeRate{stored.RateNum, stored.RateDen}, nil
+		}
+		if err := scope.Session(&gorm.Session{}).
+			Where("from_currency = ? AND to_currency = ?", to, from).
+			Order("updated_at DESC, id DESC").First(&stored).Error; err == nil {
+			return exchangeRate{stored.RateDen, stored.RateNum}, nil
+		}
+	}
+
+	return exchangeRate{}, fmt.Errorf("no exchange rate from %s to %s", from, to)
+}
+
+// convertExpense expresses what each payer paid and each split owes on an
+// expense in currency `to`, at the rate fixed on the expense. paid is aligned
+// with expensePayers(e), owed with e.Splits.
+//
+// The total is converted once, rounding half away from zero. The converted
+// total is then re-allocated over the payers and over the splits in
+// proportion to their original amounts (largest remainder), so both still
+// sum exactly to the converted total and the group's balances stay zero-sum.
+func convertExpense(e models.Expense, to string) (paid, owed []int64, err error) {
+	payerIDs, paid := expensePayers(e)
+	owed = make([]int64, len(e.Splits))
+	owerIDs := make([]uint, len(e.Splits))
+	for i, s := range e.Splits {
+		owed[i], owerIDs[i] = s.AmountOwed, s.UserID
+	}
+
+	if e.Currency == to || e.Currency == "" {
+		return paid, owed, nil
+	}
+
+	if e.RateDen == 0 {
+		return nil, nil, fmt.Errorf("no exchange rate from %s to %s", e.Currency, to)
+	}
+
+	total, err := money.Money{Amount: e.Amount, Currency: e.Currency}.Convert(to, e.RateNum, e.RateDen)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if paid, err = reallocate(total, payerIDs, paid); err != nil {
+		return nil, nil, err
+	}
+	if owed, err = reallocate(total, owerIDs, owed); err != nil {
+		return nil, nil, err
+	}
+	return paid, owed, nil
+}
+
+// reallocate distributes total in proportion to the original amounts of
+// userIDs, breaking ties by user ID like the splits themselves.
+func reallocate(total money.Money, userIDs []uint, amounts []int64) ([]int64, error) {
+	if len(amounts) == 0 {
+		return amounts, nil
+	}
+	return allocateByUser(total.Amount, userIDs, amounts)
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+	_ "time/tzdata" // IANA zones even on hosts without a zoneinfo database
+
+	"gorm.io/gorm"
+)
+
+var errInvalidDate = errors.New("use YYYY-MM-DD or an RFC 3339 timestamp")
+
+// loadLocation resolves an IANA time zone name such as "Asia/Kolkata".
+// An empty name means UTC.
+func loadLocation(tz string) (*time.Location, error) {
+	if tz == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, errors.New("unknown timezone " + tz)
+	}
+	return loc, nil
+}
+
+// parseTime reads a calendar date ("2026-10-09", taken as midnight in loc)
+// or an RFC 3339 timestamp, which carries its own offset. dateOnly reports
+// which of the two it was.
+func parseTime(value string, loc *time.Location) (t time.Time, dateOnly bool, err error) {
+	if t, err := time.ParseInLocation(time.DateOnly, value, loc); err == nil {
+		return t, true, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+	return time.Time{}, false, errInvalidDate
+}
+
+// filterExpenseDates restricts query to expenses dated within [from, to].
+// A date-only "to" includes that whole day. Dates are read in tz (IANA,
+// default UTC). Empty bounds are open.
+func filterExpenseDates(query *gorm.DB, from, to, tz string) (*gorm.DB, error) {
+	loc, err := loadLocation(tz)
+	if err != nil {
+		return nil, err
+	}
+
+	if from != "" {
+		start, _, err := parseTime(from, loc)
+		if err != nil {
+			return nil, errors.New("invalid from: " + err.Error())
+		}
+		query = query.Where("expense_date >= ?", start.UTC())
+	}
+	if to != "" {
+		end, dateOnly, err := parseTime(to, loc)
+		if err != nil {
+			return nil, errors.New("invalid to: " + err.Error())
+		}
+		if dateOnly {
+			query = query.Where("expense_date < ?", end.AddDate(0, 0, 1).UTC())
+		} else {
+			query = query.Where("expense_date <= ?", end.UTC())
+		}
+	}
+	return query, nil
+}
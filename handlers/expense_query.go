@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"splitwise-api/config"
+	"splitwise-api/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// expenseSorts maps the ?sort= options of GetExpenses to their column.
+// A leading "-" means descending.
+var expenseSorts = map[string]string{
+	"date":   "expense_date",
+	"amount": "amount",
+}
+
+// expenseCursor is the position of the last expense on a page: its sort
+// value and ID, which breaks ties between equal dates or amounts.
+type expenseCursor struct {
+	Sort   string    `json:"s"`
+	Date   time.Time `json:"d,omitempty"`
+	Amount int64     `json:"a,omitempty"`
+	ID     uint      `json:"id"`
+}
+
+// filterExpenses applies the GetExpenses query filters:
+//
+//	category_id  a category, or "none" for uncategorized
+//	paid_by      expenses the user paid, alone or as one of several payers
+//	participant  expenses the user has a share in
+//	from, to, tz expense_date range (see filterExpenseDates)
+//	min_amount, max_amount  inclusive, in minor units of each expense's currency
+//	q            case-insensitive text search in the description
+func filterExpenses(c *gin.Context, query *gorm.DB) (*gorm.DB, error) {
+	switch category := c.Query("category_id"); category {
+	case "":
+	case "none":
+		query = query.Where("category_id IS NULL")
+	default:
+		categoryID, err := strconv.ParseUint(category, 10, 64)
+		if err != nil {
+			return nil, errors.New("Invalid category_id")
+		}
+		query = query.Where("category_id = ?", categoryID)
+	}
+
+	if value := c.Query("paid_by"); value != "" {
+		userID, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, errors.New("Invalid paid_by")
+		}
+		query = query.Where("(paid_by = ? OR id IN (?))", userID,
+			config.DB.Model(&models.ExpensePayment{}).
+				Select("expense_id").Where("user_id = ?", userID))
+	}
+
+	if value := c.Query("participant"); value != "" {
+		userID, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, errors.New("Invalid participant")
+		}
+		query = query.Where("id IN (?)",
+			config.DB.Model(&models.ExpenseSplit{}).
+				Select("expense_id").Where("user_id = ?", userID))
+	}
+
+	query, err := filterExpenseDates(query, c.Query("from"), c.Query("to"), c.Query("tz"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, bound := range [][2]string{{"min_amount", ">="}, {"max_amount", "<="}} {
+		param, op := bound[0], bound[1]
+		if value := c.Query(param); value != "" {
+			amount, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, errors.New("Invalid " + param)
+			}
+			query = query.Where("amount "+op+" ?", amount)
+		}
+	}
+
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		// LIKE wildcards in the search text are matched literally
+		escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(q)
+		query = query.Where(`description LIKE ? ESCAPE '\'`, "%"+escaped+"%")
+	}
+
+	return query, nil
+}
+
+// sortExpenses orders query by ?sort= (default "-date") and, given the
+// ?cursor= of the previous page, skips to the rows after it. It returns the
+// normalized sort key for building the next cursor.
+func sortExpenses(c *gin.Context, query *gorm.DB) (*gorm.DB, string, error) {
+	sortKey := c.DefaultQuery("sort", "-date")
+	field, descending := strings.CutPrefix(sortKey, "-")
+	column, ok := expenseSorts[field]
+	if !ok {
+		return nil, "", errors.New("sort must be one of: date, -date, amount, -amount")
+	}
+
+	op, direction := ">", "ASC"
+	if descending {
+		op, direction = "<", "DESC"
+	}
+
+	if token := c.Query("cursor"); token != "" {
+		var cursor expenseCursor
+		if err := decodeCursor(token, &cursor); err != nil || cursor.Sort != sortKey {
+			return nil, "", errInvalidCursor
+		}
+		var value any = cursor.Amount
+		if field == "date" {
+			value = cursor.Date.UTC()
+		}
+		query = query.Where(fmt.Sprintf("(%s %s ? OR (%s = ? AND id %s ?))", column, op, column, op),
+			value, value, cursor.ID)
+	}
+
+	return query.Order(column + " " + direction).Order("id " + direction), sortKey, nil
+}
+
+// nextExpenseCursor is the cursor pointing after e in the given sort order.
+func nextExpenseCursor(sortKey string, e models.Expense) string {
+	return encodeCursor(expenseCursor{
+		Sort:   sortKey,
+		Date:   e.ExpenseDate,
+		Amount: e.Amount,
+		ID:     e.ID,
+	})
+}
@@ -1,24 +1,72 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"sort"
+	"splitwise-api/algorithms"
+	"splitwise-api/auth"
 	"splitwise-api/config"
 	"splitwise-api/models"
+	"splitwise-api/money"
+	"splitwise-api/search"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-// splitEntry is used for percentage and exact split inputs
+// splitEntry is used for percentage, exact, shares and adjustment split inputs
 type splitEntry struct {
-	UserID     uint  `json:"user_id"`
-	Percentage int64 `json:"percentage"` // for percentage split
-	Amount     int64 `json:"amount"`     // for exact split (paise)
+	UserID      uint  `json:"user_id"`
+	Percentage  int64 `json:"percentage"`   // for percentage split (whole percent)
+	BasisPoints int64 `json:"basis_points"` // for percentage split, instead of percentage: 1250 = 12.5%
+	Amount      int64 `json:"amount"`       // for exact split (paise)
+	Shares      int64 `json:"shares"`       // for shares split (integer weight)
+	Adjustment  int64 `json:"adjustment"`   // for adjustment split (signed paise)
+}
+
+// payerEntry is one payer of a multi-payer expense
+type payerEntry struct {
+	UserID uint  `json:"user_id"`
+	Amount int64 `json:"amount"` // in paise
+}
+
+// expenseInput is the request body for creating or replacing an expense.
+// The amount is given either in minor units (amount: 10050) or as a decimal
+// string in major units (amount_decimal: "100.50").
+type expenseInput struct {
+	PaidBy        uint         `json:"paid_by"`
+	Payers        []payerEntry `json:"payers"`         // several payers instead of paid_by; must sum to amount
+	Amount        int64        `json:"amount"`         // in paise
+	AmountDecimal string       `json:"amount_decimal"` // e.g. "100.50"
+	Currency      string       `json:"currency"`       // ISO 4217, defaults to the group currency
+	ExchangeRate  string       `json:"exchange_rate"`  // to the group currency, e.g. "83.25"; defaults to the group's current rate
+	Description   string       `json:"description"`
+	Notes         string       `json:"notes"`
+	CategoryID    uint         `json:"category_id"`  // built-in or group category; 0 = uncategorized
+	SplitType     string       `json:"split_type"`   // "equal", "percentage", "exact", "shares", "adjustment", "itemized"
+	Splits        []splitEntry `json:"splits"`       // percentage, exact, shares and adjustment splits
+	Participants  []uint       `json:"participants"` // equal split only; defaults to every member
+	Items         []itemEntry  `json:"items"`        // itemized split only
+	Tax           int64        `json:"tax"`          // itemized split only, in paise
+	Tip           int64        `json:"tip"`          // itemized split only: tip or service charge, in paise
+	ExpenseDate   string       `json:"expense_date"` // "2026-10-09" or RFC 3339; defaults to now
+	Timezone      string       `json:"timezone"`     // IANA zone for a date-only expense_date; default UTC
+
+	date       time.Time    // ExpenseDate, parsed by buildExpense; zero if not given
+	rate       exchangeRate // fixed by buildExpense; zero for the group currency
+	keptPayers bool         // Payers copied from the expense being edited; rescaled to a new amount
 }
 
 // AddExpense — POST /groups/:id/expenses
-// Supports: "equal", "percentage", "exact" split types.
+// Supports: "equal", "percentage", "exact", "shares", "adjustment", "itemized" split types.
 // All amounts are in PAISE (int64). No floats anywhere.
+// paid_by defaults to the authenticated caller when omitted; payers[]
+// replaces it when the bill was paid by several members.
 func AddExpense(c *gin.Context) {
 	groupID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
@@ -26,179 +74,950 @@ func AddExpense(c *gin.Context) {
 		return
 	}
 
-	var input struct {
-		PaidBy      uint         `json:"paid_by" binding:"required"`
-		Amount      int64        `json:"amount" binding:"required"` // in paise
-		Description string       `json:"description"`
-		SplitType   string       `json:"split_type"` // "equal", "percentage", "exact"
-		Splits      []splitEntry `json:"splits"`     // used for percentage and exact
+	var input expenseInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Verify group exists
+	var group models.Group
+	if err := config.DB.First(&group, groupID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+		return
+	}
+
+	callerID := auth.CurrentUserID(c)
+
+	if input.Currency == "" {
+		input.Currency = group.Currency
+	}
+
+	// Compute every split before touching the database, so a rejected
+	// request never leaves a half-written expense behind.
+	rows, ok := prepareExpense(c, uint(groupID), callerID, &input)
+	if !ok {
+		return
+	}
+
+	expenseDate := input.date
+	if expenseDate.IsZero() {
+		expenseDate = time.Now().UTC()
+	}
+
+	expense := models.Expense{
+		GroupID:     uint(groupID),
+		PaidBy:      input.PaidBy,
+		CreatedBy:   callerID,
+		Amount:      input.Amount,
+		Currency:    input.Currency,
+		RateNum:     input.rate.num,
+		RateDen:     input.rate.den,
+		Description: input.Description,
+		Notes:       input.Notes,
+		ExpenseDate: expenseDate,
+		SplitType:   input.SplitType,
+		CategoryID:  categoryRef(input.CategoryID),
+		Version:     1,
+		Tax:         input.Tax,
+		Tip:         input.Tip,
+	}
+
+	// Expense, splits, payers, items, its search entry and the activity
+	// are written atomically: all or nothing.
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&expense).Error; err != nil {
+			return err
+		}
+		if err := rows.create(tx, expense.ID); err != nil {
+			return err
+		}
+		if err := search.IndexExpense(tx, expense.ID); err != nil {
+			return err
+		}
+		return recordExpenseActivity(tx, models.ActivityExpenseAdded, callerID, expense, nil)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save expense"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Expense added successfully",
+		"expense": gin.H{
+			"id":               expense.ID,
+			"group_id":         expense.GroupID,
+			"paid_by":          expense.PaidBy,
+			"created_by":       expense.CreatedBy,
+			"amount":           expense.Amount,
+			"currency":         expense.Currency,
+			"rate_num":         expense.RateNum,
+			"rate_den":         expense.RateDen,
+			"amount_formatted": money.Money{Amount: expense.Amount, Currency: expense.Currency}.String(),
+			"split_type":       input.SplitType,
+			"category_id":      expense.CategoryID,
+			"description":      expense.Description,
+			"notes":            expense.Notes,
+			"expense_date":     expense.ExpenseDate,
+			"splits":           rows.Splits,
+			"payers":           rows.Payers,
+			"items":            rows.Items,
+			"tax":              expense.Tax,
+			"tip":              expense.Tip,
+		},
+	})
+}
+
+// UpdateExpense — PUT /expenses/:id
+// Replaces the amount and splits of an existing expense: amount and
+// split_type (with its splits, participants or items) are required as on
+// create. Everything else is kept when left out of the body: the payer(s),
+// currency, expense_date, description, notes and category_id. An explicit
+// empty value ("notes": "", "category_id": 0) clears notes or category.
+// The previous version is stored as an ExpenseRevision before the change.
+// An optional "version" in the body makes the edit fail with 409 if someone
+// else changed the expense in the meantime.
+func UpdateExpense(c *gin.Context) {
+	expenseID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid expense ID"})
+		return
 	}
 
+	// Pointers shadow the embedded fields, telling a field left out from
+	// one sent empty
+	var input struct {
+		expenseInput
+		Version     int     `json:"version"`
+		Description *string `json:"description"`
+		Notes       *string `json:"notes"`
+		CategoryID  *uint   `json:"category_id"`
+	}
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// ── VALIDATION GUARDS ────────────────────────────────────────────────
-	if input.Amount <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Amount must be greater than 0"})
+	var expense models.Expense
+	if err := config.DB.Preload("Splits").Preload("Payers").Preload("Items.Participants").
+		First(&expense, expenseID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Expense not found"})
 		return
 	}
 
-	if input.SplitType == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "split_type is required"})
+	if !canModifyExpense(auth.CurrentMembership(c), expense) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Your role in this group does not allow modifying this expense"})
 		return
 	}
-	// ─────────────────────────────────────────────────────────────────────
 
-	// Verify group exists
-	var group models.Group
-	if err := config.DB.First(&group, groupID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+	if input.Version != 0 && input.Version != expense.Version {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":           "Expense was modified by someone else",
+			"current_version": expense.Version,
+		})
 		return
 	}
 
-	// Verify payer is a member
-	var payer models.GroupMember
-	if err := config.DB.Where("group_id = ? AND user_id = ?", groupID, input.PaidBy).First(&payer).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Payer is not a member of this group"})
+	callerID := auth.CurrentUserID(c)
+	keepExpenseFields(&input.expenseInput, expense)
+	input.expenseInput.Description, input.expenseInput.Notes = expense.Description, expense.Notes
+	if input.Description != nil {
+		input.expenseInput.Description = *input.Description
+	}
+	if input.Notes != nil {
+		input.expenseInput.Notes = *input.Notes
+	}
+	if expense.CategoryID != nil {
+		input.expenseInput.CategoryID = *expense.CategoryID
+	}
+	if input.CategoryID != nil {
+		input.expenseInput.CategoryID = *input.CategoryID
+	}
+
+	rows, ok := prepareExpense(c, expense.GroupID, callerID, &input.expenseInput)
+	if !ok {
+		return
+	}
+
+	snapshot, err := json.Marshal(expense)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record expense history"})
 		return
 	}
+	revision := models.ExpenseRevision{
+		ExpenseID: expense.ID,
+		Version:   expense.Version,
+		EditedBy:  callerID,
+		Snapshot:  snapshot,
+	}
+
+	// The date is kept unless a new one is sent
+	expenseDate := expense.ExpenseDate
+	if !input.date.IsZero() {
+		expenseDate = input.date
+	}
+
+	// Revision, child row replacement and the expense update all commit
+	// together. Old rows are removed permanently: the revision snapshot keeps them.
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&revision).Error; err != nil {
+			return err
+		}
+		if err := deleteExpenseRows(tx.Unscoped(), expense.ID); err != nil {
+			return err
+		}
+		if err := rows.create(tx, expense.ID); err != nil {
+			return err
+		}
+
+		// Guard on the version read above so concurrent edits cannot both win
+		result := tx.Model(&models.Expense{}).
+			Where("id = ? AND version = ?", expense.ID, expense.Version).
+			Updates(map[string]interface{}{
+				"paid_by":      input.PaidBy,
+				"amount":       input.Amount,
+				"currency":     input.Currency,
+				"rate_num":     input.rate.num,
+				"rate_den":     input.rate.den,
+				"description":  input.expenseInput.Description,
+				"notes":        input.expenseInput.Notes,
+				"expense_date": expenseDate,
+				"split_type":   input.SplitType,
+				"category_id":  categoryRef(input.expenseInput.CategoryID),
+				"tax":          input.Tax,
+				"tip":          input.Tip,
+				"version":      expense.Version + 1,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errVersionConflict
+		}
+		if err := search.IndexExpense(tx, expense.ID); err != nil {
+			return err
+		}
+
+		edited := expense
+		edited.Description, edited.Amount, edited.Currency = input.expenseInput.Description, input.Amount, input.Currency
+		return recordExpenseActivity(tx, models.ActivityExpenseEdited, callerID, edited,
+			gin.H{"version": expense.Version + 1})
+	})
+	if errors.Is(err, errVersionConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Expense was modified by someone else"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update expense"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Expense updated successfully",
+		"expense": gin.H{
+			"id":               expense.ID,
+			"group_id":         expense.GroupID,
+			"paid_by":          input.PaidBy,
+			"created_by":       expense.CreatedBy,
+			"amount":           input.Amount,
+			"currency":         input.Currency,
+			"rate_num":         input.rate.num,
+			"rate_den":         input.rate.den,
+			"amount_formatted": money.Money{Amount: input.Amount, Currency: input.Currency}.String(),
+			"split_type":       input.SplitType,
+			"category_id":      categoryRef(input.expenseInput.CategoryID),
+			"description":      input.expenseInput.Description,
+			"notes":            input.expenseInput.Notes,
+			"expense_date":     expenseDate,
+			"version":          expense.Version + 1,
+			"splits":           rows.Splits,
+			"payers":           rows.Payers,
+			"items":            rows.Items,
+			"tax":              input.Tax,
+			"tip":              input.Tip,
+		},
+	})
+}
+
+// GetExpenseHistory — GET /expenses/:id/history
+// Returns every prior version of an expense, oldest first.
+func GetExpenseHistory(c *gin.Context) {
+	expenseID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid expense ID"})
+		return
+	}
+
+	var expense models.Expense
+	if err := config.DB.First(&expense, expenseID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Expense not found"})
+		return
+	}
+
+	var revisions []models.ExpenseRevision
+	config.DB.Where("expense_id = ?", expenseID).Order("version ASC").Find(&revisions)
+
+	result := []gin.H{}
+	for _, r := range revisions {
+		result = append(result, gin.H{
+			"version":   r.Version,
+			"edited_by": r.EditedBy,
+			"edited_at": r.CreatedAt,
+			"expense":   r.Snapshot,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"expense_id":      expense.ID,
+		"current_version": expense.Version,
+		"revisions":       result,
+	})
+}
+
+var errVersionConflict = errors.New("expense version conflict")
+
+// expenseRows are the child rows derived from an expenseInput. They are
+// written in the same transaction as the expense itself.
+type expenseRows struct {
+	Splits []models.ExpenseSplit
+	Payers []models.ExpensePayment
+	Items  []models.ExpenseItem
+}
+
+// create inserts the rows, attached to expenseID.
+func (r *expenseRows) create(tx *gorm.DB, expenseID uint) error {
+	for i := range r.Splits {
+		r.Splits[i].ExpenseID = expenseID
+	}
+	if err := tx.Create(&r.Splits).Error; err != nil {
+		return err
+	}
+
+	if len(r.Payers) > 0 {
+		for i := range r.Payers {
+			r.Payers[i].ExpenseID = expenseID
+		}
+		if err := tx.Create(&r.Payers).Error; err != nil {
+			return err
+		}
+	}
+
+	if len(r.Items) == 0 {
+		return nil
+	}
+	for i := range r.Items {
+		r.Items[i].ExpenseID = expenseID
+	}
+	return tx.Create(&r.Items).Error // participants are created with their item
+}
+
+// deleteExpenseRows removes the child rows of an expense. With tx as-is they
+// are soft-deleted alongside a soft-deleted expense; with tx.Unscoped() they
+// are removed for good.
+func deleteExpenseRows(tx *gorm.DB, expenseID uint) error {
+	// A new session per statement: tx.Unscoped() is not safe to chain twice,
+	// conditions and table would leak from one delete into the next.
+	db := tx.Session(&gorm.Session{})
+
+	if err := db.Where("expense_id = ?", expenseID).Delete(&models.ExpenseSplit{}).Error; err != nil {
+		return err
+	}
+	if err := db.Where("expense_id = ?", expenseID).Delete(&models.ExpensePayment{}).Error; err != nil {
+		return err
+	}
+
+	itemIDs := db.Model(&models.ExpenseItem{}).Select("id").Where("expense_id = ?", expenseID)
+	if err := db.Where("expense_item_id IN (?)", itemIDs).Delete(&models.ExpenseItemParticipant{}).Error; err != nil {
+		return err
+	}
+	return db.Where("expense_id = ?", expenseID).Delete(&models.ExpenseItem{}).Error
+}
+
+// keepExpenseFields fills in what an edit left out from the expense being
+// edited: its currency, exchange rate and who paid. Without this the payer
+// would default to the editor, moving money between members on a typo fix.
+func keepExpenseFields(input *expenseInput, expense models.Expense) {
+	if input.Currency == "" {
+		input.Currency = expense.Currency
+	}
+	// The rate stays fixed while the currency does, unless a new one is sent
+	if currency, _ := money.NormalizeCurrency(input.Currency); currency == expense.Currency {
+		input.rate = exchangeRate{expense.RateNum, expense.RateDen}
+	}
+	if input.PaidBy != 0 || len(input.Payers) > 0 {
+		return
+	}
+	if len(expense.Payers) == 0 {
+		input.PaidBy = expense.PaidBy
+		return
+	}
+	for _, p := range expense.Payers {
+		input.Payers = append(input.Payers, payerEntry{UserID: p.UserID, Amount: p.Amount})
+	}
+	input.keptPayers = true
+}
+
+// prepareExpense applies defaults to input, validates it against the group
+// and computes the splits and payers. It performs no writes. On failure it
+// has already written the error response and returns false.
+func prepareExpense(c *gin.Context, groupID, callerID uint, input *expenseInput) (expenseRows, bool) {
+	rows, splitErr := buildExpense(groupID, callerID, input)
+	if splitErr != nil {
+		c.JSON(http.StatusBadRequest, splitErr.response())
+		return rows, false
+	}
+	return rows, true
+}
+
+// buildExpense is prepareExpense without the HTTP response, for callers
+// outside a request such as the recurring expense scheduler.
+//
+// Itemized expenses derive their splits from the items; items, tax and tip
+// are rejected for every other split type.
+func buildExpense(groupID, callerID uint, input *expenseInput) (expenseRows, *splitError) {
+	var rows expenseRows
+
+	if len(input.Payers) > 0 && input.PaidBy != 0 {
+		return rows, &splitError{Message: "Send either paid_by or payers, not both"}
+	}
+	if len(input.Payers) > 0 {
+		input.PaidBy = input.Payers[0].UserID
+	}
+	if input.PaidBy == 0 {
+		input.PaidBy = callerID
+	}
+
+	// ── VALIDATION GUARDS ────────────────────────────────────────────────
+	currency, ok := money.NormalizeCurrency(input.Currency)
+	if !ok {
+		return rows, &splitError{Message: "currency must be a supported ISO 4217 code"}
+	}
+	input.Currency = currency
+	if splitErr := fixExchangeRate(groupID, input); splitErr != nil {
+		return rows, splitErr
+	}
+
+	// amount_decimal ("100.50", in major units) is an alternative to amount
+	if input.AmountDecimal != "" {
+		if input.Amount != 0 {
+			return rows, &splitError{Message: "Send either amount or amount_decimal, not both"}
+		}
+		parsed, err := money.Parse(input.AmountDecimal, input.Currency)
+		if err != nil {
+			return rows, &splitError{Message: "Invalid amount_decimal: " + err.Error()}
+		}
+		input.Amount = parsed.Amount
+	}
+
+	if input.Amount <= 0 {
+		return rows, &splitError{Message: "Amount must be greater than 0"}
+	}
+
+	if input.SplitType == "" {
+		return rows, &splitError{Message: "split_type is required"}
+	}
+
+	if input.CategoryID != 0 && !categoryAvailable(groupID, input.CategoryID) {
+		return rows, &splitError{Message: "Category does not exist in this group"}
+	}
+
+	loc, err := loadLocation(input.Timezone)
+	if err != nil {
+		return rows, &splitError{Message: err.Error()}
+	}
+	if input.ExpenseDate != "" {
+		date, _, err := parseTime(input.ExpenseDate, loc)
+		if err != nil {
+			return rows, &splitError{Message: "Invalid expense_date: " + err.Error()}
+		}
+		input.date = date.UTC()
+	}
+	// ─────────────────────────────────────────────────────────────────────
+
+	// Verify payer is a member (buildPayers checks each of several payers)
+	var payer models.GroupMember
+	if len(input.Payers) == 0 &&
+		config.DB.Where("group_id = ? AND user_id = ?", groupID, input.PaidBy).First(&payer).Error != nil {
+		return rows, &splitError{Message: "Payer is not a member of this group"}
+	}
 
 	// Fetch all group members (needed for equal split)
 	var members []models.GroupMember
 	config.DB.Where("group_id = ?", groupID).Find(&members)
-	memberCount := int64(len(members))
-	if memberCount == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Group has no members"})
-		return
+
+	payers, splitErr := buildPayers(input, members)
+	if splitErr != nil {
+		return rows, splitErr
 	}
 
-	// Create Expense record
-	expense := models.Expense{
-		GroupID:     uint(groupID),
-		PaidBy:      input.PaidBy,
-		Amount:      input.Amount,
-		Description: input.Description,
+	var items []models.ExpenseItem
+	var splits []models.ExpenseSplit
+	if input.SplitType == "itemized" {
+		items, splits, splitErr = buildItemizedSplits(input, members)
+	} else if len(input.Items) > 0 || input.Tax != 0 || input.Tip != 0 {
+		splitErr = &splitError{Message: "items, tax and tip are only used by the itemized split"}
+	} else {
+		splits, splitErr = buildSplits(input, members)
+	}
+	if splitErr != nil {
+		return rows, splitErr
+	}
+
+	rows.Splits, rows.Payers, rows.Items = splits, payers, items
+	return rows, nil
+}
+
+// fixExchangeRate sets input.rate, the rate to the group currency stored on
+// the expense: the exchange_rate sent, else the rate already set by an edit,
+// else the group's current rate. Fixing it on the expense means a rate
+// entered later cannot change existing balances.
+func fixExchangeRate(groupID uint, input *expenseInput) *splitError {
+	var group models.Group
+	if err := config.DB.First(&group, groupID).Error; err != nil {
+		return &splitError{Message: "Group not found"}
+	}
+
+	if input.Currency == group.Currency {
+		if input.ExchangeRate != "" {
+			return &splitError{Message: "exchange_rate is only used for expenses in another currency than the group's"}
+		}
+		input.rate = exchangeRate{}
+		return nil
+	}
+
+	if input.ExchangeRate != "" {
+		num, den, err := money.ParseRate(input.ExchangeRate)
+		if err != nil {
+			return &splitError{Message: "Invalid exchange_rate: " + err.Error()}
+		}
+		input.rate = exchangeRate{num, den}
+		return nil
+	}
+	if input.rate.den != 0 {
+		return nil
+	}
+
+	rate, err := findExchangeRate(groupID, input.Currency, group.Currency)
+	if err != nil {
+		return &splitError{Message: "No exchange rate from " + input.Currency + " to " + group.Currency +
+			"; add one to the group or send exchange_rate"}
 	}
-	config.DB.Create(&expense)
+	input.rate = rate
+	return nil
+}
+
+// splitError describes why a split definition was rejected.
+// Details are merged into the 400 response next to "error"; Fields, when
+// set, list every invalid field so clients can point at them:
+//
+//	{"error": "Validation failed", "fields": [{"field": "splits[1].user_id", "message": "..."}]}
+type splitError struct {
+	Message string
+	Details gin.H
+	Fields  []fieldError
+}
+
+// fieldError is one invalid field of an expense request.
+type fieldError struct {
+	Field   string `json:"field"` // path into the body, e.g. "splits[1].user_id"
+	Message string `json:"message"`
+}
+
+// validationFailed wraps the field errors of a request in a splitError.
+func validationFailed(fields []fieldError) *splitError {
+	return &splitError{Message: "Validation failed", Fields: fields}
+}
+
+func (e *splitError) response() gin.H {
+	body := gin.H{"error": e.Message}
+	for k, v := range e.Details {
+		body[k] = v
+	}
+	if len(e.Fields) > 0 {
+		body["fields"] = e.Fields
+	}
+	return body
+}
 
+// buildSplits validates a split definition and computes each member's share.
+// It performs no writes; the returned splits have no ExpenseID yet.
+func buildSplits(input *expenseInput, members []models.GroupMember) ([]models.ExpenseSplit, *splitError) {
+	amount, entries := input.Amount, input.Splits
 	var splits []models.ExpenseSplit
 
+	// Every split type except equal names its users in splits[]
+	switch input.SplitType {
+	case "percentage", "exact", "shares", "adjustment":
+		if len(entries) == 0 {
+			return nil, &splitError{Message: "Provide splits[] for " + input.SplitType + " split"}
+		}
+		if fields := checkSplitEntries(input.SplitType, entries, members); len(fields) > 0 {
+			return nil, validationFailed(fields)
+		}
+	}
+
 	switch input.SplitType {
 
-	// ── EQUAL SPLIT (original logic — UNTOUCHED) ─────────────────────────
+	// ── EQUAL SPLIT ───────────────────────────────────────────────────────
 	case "equal", "":
+		// Split among the chosen participants, or every member if none given
+		participants := input.Participants
+		if len(participants) == 0 {
+			for _, m := range members {
+				participants = append(participants, m.UserID)
+			}
+		} else if fields := checkParticipants("participants[%d]", participants, members); len(fields) > 0 {
+			return nil, validationFailed(fields)
+		}
+
+		if len(participants) == 0 {
+			return nil, &splitError{Message: "Group has no members"}
+		}
 		// Equal split with rounding correction.
 		// e.g., ₹100 among 3 → 3334, 3333, 3333 paise  (total = 10000 ✅)
-		baseShare := input.Amount / memberCount
-		remainder := input.Amount % memberCount
-		for idx, m := range members {
-			share := baseShare
-			if int64(idx) < remainder {
-				share++ // distribute 1 extra paise to first `remainder` members
-			}
+		shares, err := allocateEqually(amount, participants)
+		if err != nil {
+			return nil, &splitError{Message: err.Error()}
+		}
+		for idx, userID := range participants {
 			splits = append(splits, models.ExpenseSplit{
-				ExpenseID:  expense.ID,
-				UserID:     m.UserID,
-				AmountOwed: share,
+				UserID:     userID,
+				AmountOwed: shares[idx],
 			})
 		}
 
 	// ── PERCENTAGE SPLIT ──────────────────────────────────────────────────
 	case "percentage":
-		if len(input.Splits) == 0 {
-			config.DB.Delete(&expense)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Provide splits[] for percentage split"})
-			return
-		}
-		// Validate: percentages must sum to exactly 100
-		var totalPct int64
-		for _, s := range input.Splits {
-			totalPct += s.Percentage
-		}
-		if totalPct != 100 {
-			config.DB.Delete(&expense)
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error":    "Percentages must sum to 100",
-				"got":      totalPct,
-				"expected": 100,
-			})
-			return
-		}
-		// Integer math only — no float64.
-		// Remainder assigned to last user to guarantee total conservation.
-		var allocated int64
-		for idx, s := range input.Splits {
-			var share int64
-			if idx == len(input.Splits)-1 {
-				share = input.Amount - allocated // absorb any rounding remainder
-			} else {
-				share = input.Amount * s.Percentage / 100
+		// Percentages are handled in basis points (1/100 of a percent), so
+		// 12.5% is 1250. Validate: they must sum to exactly 100% = 10000.
+		weights := make([]int64, len(entries))
+		var totalBP int64
+		for i, s := range entries {
+			bp, err := basisPoints(s)
+			if err != nil {
+				return nil, &splitError{Message: err.Error()}
+			}
+			if totalBP, err = money.AddInt64(totalBP, bp); err != nil {
+				return nil, &splitError{Message: "Percentages overflow"}
+			}
+			weights[i] = bp
+		}
+		if totalBP != 10000 {
+			return nil, &splitError{
+				Message: "Percentages must sum to 100",
+				Details: gin.H{"got_basis_points": totalBP, "expected_basis_points": 10000},
 			}
-			allocated += share
+		}
+		// Largest remainder allocation, ties broken by user ID: the result
+		// does not depend on the order of the entries, and the total is
+		// always conserved.
+		// e.g., ₹100 as 33.33/33.33/33.34% → 3333, 3333, 3334 paise
+		amounts, err := allocateByUser(amount, splitUserIDs(entries), weights)
+		if err != nil {
+			return nil, &splitError{Message: err.Error()}
+		}
+		for i, s := range entries {
 			splits = append(splits, models.ExpenseSplit{
-				ExpenseID:  expense.ID,
 				UserID:     s.UserID,
-				AmountOwed: share,
+				AmountOwed: amounts[i],
 			})
 		}
 
 	// ── EXACT SPLIT ───────────────────────────────────────────────────────
 	case "exact":
-		if len(input.Splits) == 0 {
-			config.DB.Delete(&expense)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Provide splits[] for exact split"})
-			return
-		}
 		// Validate: exact amounts must sum to total expense amount
 		var total int64
-		for _, s := range input.Splits {
-			total += s.Amount
-		}
-		if total != input.Amount {
-			config.DB.Delete(&expense)
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error":    "Exact split amounts do not sum to total expense amount",
-				"expected": input.Amount,
-				"got":      total,
-			})
-			return
+		for _, s := range entries {
+			var err error
+			if total, err = money.AddInt64(total, s.Amount); err != nil {
+				return nil, &splitError{Message: "Exact split amounts overflow"}
+			}
+		}
+		if total != amount {
+			return nil, &splitError{
+				Message: "Exact split amounts do not sum to total expense amount",
+				Details: gin.H{"expected": amount, "got": total},
+			}
 		}
-		for _, s := range input.Splits {
+		for _, s := range entries {
 			splits = append(splits, models.ExpenseSplit{
-				ExpenseID:  expense.ID,
 				UserID:     s.UserID,
 				AmountOwed: s.Amount,
 			})
 		}
 
+	// ── SHARES SPLIT ──────────────────────────────────────────────────────
+	case "shares":
+		// Each participant carries an integer weight, e.g. a couple 2, a kid 1
+		weights := make([]int64, len(entries))
+		for i, s := range entries {
+			weights[i] = s.Shares
+		}
+		// Largest remainder allocation: shares sum exactly to the amount.
+		// e.g., ₹100 as 2:1 → 6667, 3333 paise  (total = 10000 ✅)
+		amounts, err := allocateByUser(amount, splitUserIDs(entries), weights)
+		if err != nil {
+			return nil, &splitError{Message: err.Error()}
+		}
+		for i, s := range entries {
+			splits = append(splits, models.ExpenseSplit{
+				UserID:     s.UserID,
+				AmountOwed: amounts[i],
+			})
+		}
+
+	// ── ADJUSTMENT SPLIT ──────────────────────────────────────────────────
+	case "adjustment":
+		// "Split equally, but Arjun had an extra ₹150 drink": adjustments are
+		// taken off the top, the rest is divided equally, then added back.
+		var totalAdj int64
+		for _, s := range entries {
+			var err error
+			if totalAdj, err = money.AddInt64(totalAdj, s.Adjustment); err != nil {
+				return nil, &splitError{Message: "Adjustments overflow"}
+			}
+		}
+		remaining := amount - totalAdj
+		if remaining < 0 {
+			return nil, &splitError{
+				Message: "Adjustments exceed the expense amount",
+				Details: gin.H{"amount": amount, "total_adjustment": totalAdj},
+			}
+		}
+
+		// Same rounding rule as the equal split
+		equalShares, err := allocateEqually(remaining, splitUserIDs(entries))
+		if err != nil {
+			return nil, &splitError{Message: err.Error()}
+		}
+		for idx, s := range entries {
+			share := equalShares[idx] + s.Adjustment
+			if share < 0 {
+				return nil, &splitError{
+					Message: "Adjustment makes a participant's share negative",
+					Details: gin.H{"user_id": s.UserID, "share": share},
+				}
+			}
+			splits = append(splits, models.ExpenseSplit{
+				UserID:     s.UserID,
+				AmountOwed: share,
+			})
+		}
+
 	default:
-		config.DB.Delete(&expense)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid split_type. Must be one of: equal, percentage, exact",
-		})
-		return
+		return nil, &splitError{Message: "Invalid split_type. Must be one of: equal, percentage, exact, shares, adjustment, itemized"}
 	}
 
-	// Bulk insert splits
-	config.DB.Create(&splits)
+	return splits, nil
+}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "Expense added successfully",
-		"expense": gin.H{
-			"id":          expense.ID,
-			"group_id":    expense.GroupID,
-			"paid_by":     expense.PaidBy,
-			"amount":      expense.Amount,
-			"split_type":  input.SplitType,
-			"description": expense.Description,
-			"splits":      splits,
-		},
-	})
+// checkSplitEntries validates every entry of splits[] for the given split
+// type and reports all problems at once: unknown or non-member users, users
+// listed twice, and amounts, percentages or shares that are out of range.
+func checkSplitEntries(splitType string, entries []splitEntry, members []models.GroupMember) []fieldError {
+	fields := checkParticipants("splits[%d].user_id", splitUserIDs(entries), members)
+
+	for i, s := range entries {
+		switch splitType {
+		case "percentage":
+			field := fmt.Sprintf("splits[%d].percentage", i)
+			if s.BasisPoints != 0 {
+				field = fmt.Sprintf("splits[%d].basis_points", i)
+			}
+			if bp, err := basisPoints(s); err != nil {
+				fields = append(fields, fieldError{Field: field, Message: err.Error()})
+			} else if bp == 0 {
+				fields = append(fields, fieldError{Field: field, Message: "Percentage must be greater than 0"})
+			}
+		case "exact":
+			if s.Amount < 0 {
+				fields = append(fields, fieldError{
+					Field:   fmt.Sprintf("splits[%d].amount", i),
+					Message: "Amount cannot be negative",
+				})
+			}
+		case "shares":
+			if s.Shares <= 0 {
+				fields = append(fields, fieldError{
+					Field:   fmt.Sprintf("splits[%d].shares", i),
+					Message: "Each participant must have at least 1 share",
+				})
+			}
+		}
+	}
+	return fields
+}
+
+// basisPoints returns a percentage split entry's weight in basis points.
+// An entry gives either a whole "percentage" or exact "basis_points".
+func basisPoints(s splitEntry) (int64, error) {
+	if s.Percentage != 0 && s.BasisPoints != 0 {
+		return 0, errors.New("Send either percentage or basis_points, not both")
+	}
+
+	bp := s.BasisPoints
+	if s.Percentage != 0 {
+		var err error
+		if bp, err = money.MulInt64(s.Percentage, 100); err != nil {
+			return 0, errors.New("Percentage is too large")
+		}
+	}
+	if bp < 0 {
+		return 0, errors.New("Percentage cannot be negative")
+	}
+	return bp, nil
+}
+
+// allocateEqually splits total between userIDs in parts that differ by at
+// most one paisa. The extra paise go to the lowest user IDs, e.g. ₹100 among
+// users 3, 1, 2 → 3333, 3334, 3333.
+func allocateEqually(total int64, userIDs []uint) ([]int64, error) {
+	weights := make([]int64, len(userIDs))
+	for i := range weights {
+		weights[i] = 1
+	}
+	return allocateByUser(total, userIDs, weights)
+}
+
+// allocateByUser is algorithms.Allocate with ties broken by user ID instead
+// of position: equal remainders give the extra paisa to the lowest user ID
+// first, so the same split sent in any order gives everyone the same amount.
+// The result is aligned with userIDs.
+func allocateByUser(total int64, userIDs []uint, weights []int64) ([]int64, error) {
+	order := make([]int, len(userIDs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return userIDs[order[a]] < userIDs[order[b]] })
+
+	sorted := make([]int64, len(order))
+	for k, i := range order {
+		sorted[k] = weights[i]
+	}
+	parts, err := algorithms.Allocate(total, sorted)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]int64, len(order))
+	for k, i := range order {
+		result[i] = parts[k]
+	}
+	return result, nil
+}
+
+// splitUserIDs returns the user of each entry of splits[], in order.
+func splitUserIDs(entries []splitEntry) []uint {
+	userIDs := make([]uint, len(entries))
+	for i, s := range entries {
+		userIDs[i] = s.UserID
+	}
+	return userIDs
+}
+
+// buildPayers validates the payers of a multi-payer expense: each must be a
+// group member listed once, paying a positive amount, and together they must
+// pay exactly the expense amount. Single-payer expenses yield no rows.
+func buildPayers(input *expenseInput, members []models.GroupMember) ([]models.ExpensePayment, *splitError) {
+	if len(input.Payers) == 0 {
+		return nil, nil
+	}
+
+	userIDs := make([]uint, len(input.Payers))
+	for i, p := range input.Payers {
+		userIDs[i] = p.UserID
+	}
+	fields := checkParticipants("payers[%d].user_id", userIDs, members)
+	for i, p := range input.Payers {
+		if p.Amount <= 0 {
+			fields = append(fields, fieldError{
+				Field:   fmt.Sprintf("payers[%d].amount", i),
+				Message: "Each payer must pay more than 0",
+			})
+		}
+	}
+	if len(fields) > 0 {
+		return nil, validationFailed(fields)
+	}
+
+	var total int64
+	payers := make([]models.ExpensePayment, len(input.Payers))
+	for i, p := range input.Payers {
+		var err error
+		if total, err = money.AddInt64(total, p.Amount); err != nil {
+			return nil, &splitError{Message: "Payer amounts overflow"}
+		}
+		payers[i] = models.ExpensePayment{UserID: p.UserID, Amount: p.Amount}
+	}
+
+	// An edit that only changes the amount keeps who paid, in the same
+	// proportions as before
+	if input.keptPayers && total != input.Amount {
+		weights := make([]int64, len(payers))
+		for i, p := range payers {
+			weights[i] = p.Amount
+		}
+		amounts, err := allocateByUser(input.Amount, userIDs, weights)
+		if err != nil {
+			return nil, &splitError{Message: "Invalid amount: " + err.Error()}
+		}
+		for i, amount := range amounts {
+			if amount <= 0 {
+				return nil, &splitError{Message: "The new amount is too small to keep every payer; send payers with the amount"}
+			}
+			payers[i].Amount = amount
+		}
+		total = input.Amount
+	}
+
+	if total != input.Amount {
+		return nil, &splitError{
+			Message: "Payer amounts do not sum to total expense amount",
+			Details: gin.H{"expected": input.Amount, "got": total},
+		}
+	}
+	return payers, nil
+}
+
+// checkParticipants reports every entry of a user list that is missing,
+// names a non-member or repeats a user. field is a format for the entry's
+// path, e.g. "payers[%d].user_id".
+func checkParticipants(field string, participants []uint, members []models.GroupMember) []fieldError {
+	isMember := make(map[uint]bool, len(members))
+	for _, m := range members {
+		isMember[m.UserID] = true
+	}
+
+	var fields []fieldError
+	seen := make(map[uint]bool, len(participants))
+	for i, userID := range participants {
+		var message string
+		switch {
+		case userID == 0:
+			message = "user_id is required"
+		case !isMember[userID]:
+			message = "User " + strconv.FormatUint(uint64(userID), 10) + " is not a member of this group"
+		case seen[userID]:
+			message = "User " + strconv.FormatUint(uint64(userID), 10) + " is listed more than once"
+		}
+		if message != "" {
+			fields = append(fields, fieldError{Field: fmt.Sprintf(field, i), Message: message})
+		}
+		seen[userID] = true
+	}
+	return fields
 }
 
 // GetExpenses — GET /groups/:id/expenses
+// Returns one page of expenses, newest expense_date first by default.
+//
+// ?sort=         date, -date (default), amount, -amount
+// ?limit=        page size, default 50, at most 200
+// ?cursor=       page.next_cursor of the previous page
+//
+// Filters (see filterExpenses): category_id, paid_by, participant,
+// from/to/tz, min_amount/max_amount and q (description search).
+// Each expense carries its comment_count.
 func GetExpenses(c *gin.Context) {
 	groupID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
@@ -206,10 +1025,57 @@ func GetExpenses(c *gin.Context) {
 		return
 	}
 
+	limit, err := pageLimit(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query, err := filterExpenses(c, config.DB.Where("group_id = ?", groupID))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query, sortKey, err := sortExpenses(c, query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// One extra row tells whether another page follows
 	var expenses []models.Expense
-	config.DB.Where("group_id = ?", groupID).Preload("Splits").Find(&expenses)
+	query.Limit(limit + 1).
+		Preload("Splits").Preload("Payers").Preload("Items.Participants").Preload("Attachments").
+		Find(&expenses)
 
-	c.JSON(http.StatusOK, gin.H{"expenses": expenses})
+	hasMore := len(expenses) > limit
+	nextCursor := ""
+	if hasMore {
+		expenses = expenses[:limit]
+		nextCursor = nextExpenseCursor(sortKey, expenses[limit-1])
+	}
+
+	ids := make([]uint, len(expenses))
+	for i, e := range expenses {
+		ids[i] = e.ID
+	}
+	counts := commentCounts(ids)
+
+	result := make([]expenseListItem, len(expenses))
+	for i, e := range expenses {
+		result[i] = expenseListItem{Expense: e, CommentCount: counts[e.ID]}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"expenses": result,
+		"page":     pageEnvelope(limit, hasMore, nextCursor),
+	})
+}
+
+// expenseListItem is an expense as listed by GetExpenses.
+type expenseListItem struct {
+	models.Expense
+	CommentCount int64 `json:"comment_count"`
 }
 
 // DeleteExpense — DELETE /expenses/:id
@@ -226,9 +1092,50 @@ func DeleteExpense(c *gin.Context) {
 		return
 	}
 
-	// Delete associated splits first, then the expense
-	config.DB.Where("expense_id = ?", expenseID).Delete(&models.ExpenseSplit{})
-	config.DB.Delete(&expense)
+	if !canModifyExpense(auth.CurrentMembership(c), expense) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Your role in this group does not allow modifying this expense"})
+		return
+	}
+
+	// Delete associated splits, payers and items, the search entry and the
+	// expense together. Comments stay as they are: they are only reachable
+	// through the expense.
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := deleteExpenseRows(tx, expense.ID); err != nil {
+			return err
+		}
+		if err := search.RemoveExpense(tx, expense.ID); err != nil {
+			return err
+		}
+		if err := tx.Delete(&expense).Error; err != nil {
+			return err
+		}
+		return recordExpenseActivity(tx, models.ActivityExpenseDeleted, auth.CurrentUserID(c), expense, nil)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete expense"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Expense deleted successfully"})
 }
+
+// canModify reports whether a member may change or delete something ownerID
+// created: an expense, payment, template, comment or attachment. Their own
+// need ownPerm; anyone else's need PermModifyAnyExpense.
+func canModify(member models.GroupMember, ownerID uint, ownPerm auth.Permission) bool {
+	if ownerID == member.UserID {
+		return auth.Can(member.Role, ownPerm)
+	}
+	return auth.Can(member.Role, auth.PermModifyAnyExpense)
+}
+
+// canModifyExpense reports whether a member may edit, delete or restore an
+// expense.
+func canModifyExpense(member models.GroupMember, expense models.Expense) bool {
+	recorder := expense.CreatedBy
+	if recorder == 0 {
+		recorder = expense.PaidBy // recorded before created_by existed
+	}
+	return canModify(member, recorder, auth.PermModifyOwnExpense)
+}
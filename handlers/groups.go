@@ -1,19 +1,25 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"splitwise-api/auth"
 	"splitwise-api/config"
 	"splitwise-api/models"
+	"splitwise-api/money"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // CreateGroup — POST /groups
+// The authenticated caller becomes the group's creator.
+// currency (ISO 4217) defaults to INR and cannot be changed later.
 func CreateGroup(c *gin.Context) {
 	var input struct {
-		Name      string `json:"name" binding:"required"`
-		CreatedBy uint   `json:"created_by" binding:"required"`
+		Name     string `json:"name" binding:"required"`
+		Currency string `json:"currency"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -21,30 +27,53 @@ func CreateGroup(c *gin.Context) {
 		return
 	}
 
-	// Verify creator exists
-	var creator models.User
-	if err := config.DB.First(&creator, input.CreatedBy).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Creator user not found"})
+	if input.Currency == "" {
+		input.Currency = "INR"
+	}
+	currency, ok := money.NormalizeCurrency(input.Currency)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "currency must be a supported ISO 4217 code"})
 		return
 	}
 
+	creatorID := auth.CurrentUserID(c)
+
 	group := models.Group{
 		Name:      input.Name,
-		CreatedBy: input.CreatedBy,
+		Currency:  currency,
+		CreatedBy: creatorID,
 	}
-	config.DB.Create(&group)
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&group).Error; err != nil {
+			return err
+		}
 
-	// Auto-add creator as a member
-	member := models.GroupMember{GroupID: group.ID, UserID: input.CreatedBy}
-	config.DB.Create(&member)
+		// Auto-add creator as the group's owner
+		member := models.GroupMember{GroupID: group.ID, UserID: creatorID, Role: models.RoleOwner}
+		if err := tx.Create(&member).Error; err != nil {
+			return err
+		}
+
+		return recordActivity(tx, models.Activity{
+			GroupID: group.ID,
+			ActorID: creatorID,
+			Type:    models.ActivityGroupCreated,
+		}, gin.H{"name": group.Name, "currency": group.Currency})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create group"})
+		return
+	}
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Group created successfully",
-		"group":   gin.H{"id": group.ID, "name": group.Name, "created_by": group.CreatedBy},
+		"group":   gin.H{"id": group.ID, "name": group.Name, "currency": group.Currency, "created_by": group.CreatedBy},
 	})
 }
 
 // AddMember — POST /groups/:id/members
+// role defaults to "member"; adding an admin or viewer directly requires
+// permission to manage roles.
 func AddMember(c *gin.Context) {
 	groupID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
@@ -53,13 +82,26 @@ func AddMember(c *gin.Context) {
 	}
 
 	var input struct {
-		UserID uint `json:"user_id" binding:"required"`
+		UserID uint   `json:"user_id" binding:"required"`
+		Role   string `json:"role"`
 	}
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	if input.Role == "" {
+		input.Role = models.RoleMember
+	}
+	if !auth.ValidRole(input.Role) || input.Role == models.RoleOwner {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role. Must be one of: admin, member, viewer"})
+		return
+	}
+	if input.Role != models.RoleMember && !auth.Can(auth.CurrentMembership(c).Role, auth.PermManageRoles) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the group owner can add members with the " + input.Role + " role"})
+		return
+	}
+
 	// Verify group exists
 	var group models.Group
 	if err := config.DB.First(&group, groupID).Error; err != nil {
@@ -82,13 +124,28 @@ func AddMember(c *gin.Context) {
 		return
 	}
 
-	member := models.GroupMember{GroupID: uint(groupID), UserID: input.UserID}
-	config.DB.Create(&member)
+	member := models.GroupMember{GroupID: uint(groupID), UserID: input.UserID, Role: input.Role}
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&member).Error; err != nil {
+			return err
+		}
+		return recordActivity(tx, models.Activity{
+			GroupID: uint(groupID),
+			ActorID: auth.CurrentUserID(c),
+			Type:    models.ActivityMemberJoined,
+			UserID:  idRef(input.UserID),
+		}, gin.H{"name": user.Name, "role": member.Role})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add member"})
+		return
+	}
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message":  "Member added successfully",
 		"group_id": groupID,
 		"user_id":  input.UserID,
+		"role":     member.Role,
 	})
 }
 
@@ -118,6 +175,7 @@ func GetGroup(c *gin.Context) {
 			"user_id": user.ID,
 			"name":    user.Name,
 			"email":   user.Email,
+			"role":    m.Role,
 		})
 	}
 
@@ -125,9 +183,331 @@ func GetGroup(c *gin.Context) {
 		"group": gin.H{
 			"id":         group.ID,
 			"name":       group.Name,
+			"currency":   group.Currency,
 			"created_by": group.CreatedBy,
 			"created_at": group.CreatedAt,
 			"members":    memberDetails,
 		},
 	})
 }
+
+// RenameGroup — PATCH /groups/:id
+func RenameGroup(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	var input struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var group models.Group
+	if err := config.DB.First(&group, groupID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+		return
+	}
+
+	oldName := group.Name
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&group).Update("name", input.Name).Error; err != nil {
+			return err
+		}
+		return recordActivity(tx, models.Activity{
+			GroupID: group.ID,
+			ActorID: auth.CurrentUserID(c),
+			Type:    models.ActivityGroupRenamed,
+		}, gin.H{"old_name": oldName, "new_name": input.Name})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rename group"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Group renamed successfully",
+		"group":   gin.H{"id": group.ID, "name": group.Name},
+	})
+}
+
+// DeleteGroup — DELETE /groups/:id
+// Soft-deletes the group, its memberships and its recurring expenses, which
+// would otherwise keep failing. Expenses are kept for audit.
+func DeleteGroup(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	var group models.Group
+	if err := config.DB.First(&group, groupID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("group_id = ?", groupID).Delete(&models.GroupMember{}).Error; err != nil {
+			return err
+		}
+		// Stop the group's recurring expenses: they could only fail from now on
+		if err := tx.Where("group_id = ?", groupID).Delete(&models.RecurringExpense{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&group).Error; err != nil {
+			return err
+		}
+		return recordActivity(tx, models.Activity{
+			GroupID: group.ID,
+			ActorID: auth.CurrentUserID(c),
+			Type:    models.ActivityGroupDeleted,
+		}, gin.H{"name": group.Name})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete group"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Group deleted successfully"})
+}
+
+var errMemberNotRemovable = errors.New("member cannot be removed")
+
+// RemoveMember — DELETE /groups/:id/members/:user_id
+// Any member may remove themselves (leave); removing someone else needs
+// PermRemoveMember, and PermManageRoles if they are an admin. The owner must
+// transfer ownership first, and nobody leaves with an unsettled balance.
+// Recurring templates that pay for or split with the member are stopped.
+func RemoveMember(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var member models.GroupMember
+	if err := config.DB.Where("group_id = ? AND user_id = ?", groupID, userID).First(&member).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User is not a member of this group"})
+		return
+	}
+
+	current := auth.CurrentMembership(c)
+	leaving := member.UserID == current.UserID
+	if !leaving {
+		required := auth.PermRemoveMember
+		if member.Role == models.RoleAdmin {
+			required = auth.PermManageRoles
+		}
+		if !auth.Can(current.Role, required) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":    "Your role in this group does not allow this action",
+				"role":     current.Role,
+				"required": required,
+			})
+			return
+		}
+	}
+	if member.Role == models.RoleOwner {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The owner cannot leave or be removed; transfer ownership first"})
+		return
+	}
+
+	var group models.Group
+	if err := config.DB.First(&group, groupID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+		return
+	}
+
+	var user models.User
+	config.DB.First(&user, member.UserID)
+
+	kind := models.ActivityMemberRemoved
+	if leaving {
+		kind = models.ActivityMemberLeft
+	}
+
+	// The balance is checked inside the transaction, after the delete has
+	// taken SQLite's write lock, so it cannot change before the commit
+	var refusal gin.H
+	refusalStatus := http.StatusConflict
+	var stopped []uint
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&member).Error; err != nil {
+			return err
+		}
+
+		balances, err := computeNetBalances(tx, group)
+		if err != nil {
+			refusal, refusalStatus = gin.H{"error": err.Error()}, http.StatusUnprocessableEntity
+			return errMemberNotRemovable
+		}
+		if balance := balances[member.UserID]; balance != 0 {
+			refusal = gin.H{
+				"error":             "Balance must be settled before leaving the group",
+				"balance":           balance,
+				"balance_formatted": money.Money{Amount: balance, Currency: group.Currency}.String(),
+			}
+			return errMemberNotRemovable
+		}
+
+		if stopped, err = stopTemplatesWith(tx, group.ID, member.UserID, current.UserID); err != nil {
+			return err
+		}
+		return recordActivity(tx, models.Activity{
+			GroupID: uint(groupID),
+			ActorID: current.UserID,
+			Type:    kind,
+			UserID:  idRef(member.UserID),
+		}, gin.H{"name": user.Name, "role": member.Role})
+	})
+	if refusal != nil {
+		c.JSON(refusalStatus, refusal)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member"})
+		return
+	}
+
+	message := "Member removed successfully"
+	if leaving {
+		message = "You left the group"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":                    message,
+		"group_id":                   groupID,
+		"user_id":                    member.UserID,
+		"stopped_recurring_expenses": stopped,
+	})
+}
+
+// UpdateMemberRole — PUT /groups/:id/members/:user_id/role
+// Changes a member's role. Ownership cannot be granted or removed here;
+// use TransferOwnership instead.
+func UpdateMemberRole(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var input struct {
+		Role string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !auth.ValidRole(input.Role) || input.Role == models.RoleOwner {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role. Must be one of: admin, member, viewer"})
+		return
+	}
+
+	var member models.GroupMember
+	if err := config.DB.Where("group_id = ? AND user_id = ?", groupID, userID).First(&member).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User is not a member of this group"})
+		return
+	}
+
+	if member.Role == models.RoleOwner {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The owner's role can only change through an ownership transfer"})
+		return
+	}
+
+	oldRole := member.Role
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&member).Update("role", input.Role).Error; err != nil {
+			return err
+		}
+		return recordActivity(tx, models.Activity{
+			GroupID: uint(groupID),
+			ActorID: auth.CurrentUserID(c),
+			Type:    models.ActivityMemberRoleChanged,
+			UserID:  idRef(member.UserID),
+		}, gin.H{"old_role": oldRole, "new_role": input.Role})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update member role"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Member role updated successfully",
+		"group_id": groupID,
+		"user_id":  userID,
+		"role":     member.Role,
+	})
+}
+
+// TransferOwnership — POST /groups/:id/transfer-ownership
+// Makes another member the owner; the previous owner becomes an admin.
+func TransferOwnership(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	var input struct {
+		UserID uint `json:"user_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	current := auth.CurrentMembership(c)
+	if input.UserID == current.UserID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You already own this group"})
+		return
+	}
+
+	var target models.GroupMember
+	if err := config.DB.Where("group_id = ? AND user_id = ?", groupID, input.UserID).First(&target).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User is not a member of this group"})
+		return
+	}
+
+	// Both role changes happen together so the group never has zero or two owners
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&current).Update("role", models.RoleAdmin).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&target).Update("role", models.RoleOwner).Error; err != nil {
+			return err
+		}
+		return recordActivity(tx, models.Activity{
+			GroupID: uint(groupID),
+			ActorID: current.UserID,
+			Type:    models.ActivityOwnershipTransferred,
+			UserID:  idRef(target.UserID),
+		}, gin.H{"previous_owner": current.UserID})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to transfer ownership"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Ownership transferred successfully",
+		"group_id":       groupID,
+		"owner_id":       target.UserID,
+		"previous_owner": current.UserID,
+	})
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"fmt"
+	"splitwise-api/models"
+	"splitwise-api/money"
+
+	"github.com/gin-gonic/gin"
+)
+
+// itemEntry is one line of an itemized receipt
+type itemEntry struct {
+	Description  string `json:"description"`
+	Amount       int64  `json:"amount"`       // in paise
+	Participants []uint `json:"participants"` // members who shared this item
+}
+
+// buildItemizedSplits validates an itemized receipt and derives each member's
+// share from it. It performs no writes; the returned rows have no ExpenseID.
+//
+//  1. Every item is split equally among its participants
+//     (largest remainder, so e.g. ₹100 among 3 → 3334, 3333, 3333 paise).
+//  2. Tax and tip are split in proportion to each member's item subtotal,
+//     so whoever ordered more also pays more of the service charge.
+//
+// Items, tax and tip must sum exactly to the expense amount.
+func buildItemizedSplits(input *expenseInput, members []models.GroupMember) ([]models.ExpenseItem, []models.ExpenseSplit, *splitError) {
+	if len(input.Items) == 0 {
+		return nil, nil, &splitError{Message: "Provide items[] for itemized split"}
+	}
+	if input.Tax < 0 || input.Tip < 0 {
+		return nil, nil, &splitError{
+			Message: "tax and tip cannot be negative",
+			Details: gin.H{"tax": input.Tax, "tip": input.Tip},
+		}
+	}
+
+	// Item subtotal per member, in order of first appearance
+	var userIDs []uint
+	subtotals := make(map[uint]int64)
+
+	if fields := checkItems(input.Items, members); len(fields) > 0 {
+		return nil, nil, validationFailed(fields)
+	}
+
+	var total int64
+	items := make([]models.ExpenseItem, len(input.Items))
+	for i, entry := range input.Items {
+		var err error
+		if total, err = money.AddInt64(total, entry.Amount); err != nil {
+			return nil, nil, &splitError{Message: "Item amounts overflow"}
+		}
+
+		shares, err := allocateEqually(entry.Amount, entry.Participants)
+		if err != nil {
+			return nil, nil, &splitError{Message: err.Error()}
+		}
+
+		item := models.ExpenseItem{Description: entry.Description, Amount: entry.Amount}
+		for k, userID := range entry.Participants {
+			if _, ok := subtotals[userID]; !ok {
+				userIDs = append(userIDs, userID)
+			}
+			subtotals[userID] += shares[k] // ≤ total, which did not overflow
+			item.Participants = append(item.Participants, models.ExpenseItemParticipant{
+				UserID:     userID,
+				AmountOwed: shares[k],
+			})
+		}
+		items[i] = item
+	}
+
+	extras, err := money.AddInt64(input.Tax, input.Tip)
+	if err == nil {
+		total, err = money.AddInt64(total, extras)
+	}
+	if err != nil {
+		return nil, nil, &splitError{Message: "Item amounts overflow"}
+	}
+	if total != input.Amount {
+		return nil, nil, &splitError{
+			Message: "Items, tax and tip do not sum to total expense amount",
+			Details: gin.H{"expected": input.Amount, "got": total},
+		}
+	}
+
+	// Tax and tip together, weighted by item subtotal
+	weights := make([]int64, len(userIDs))
+	for i, userID := range userIDs {
+		weights[i] = subtotals[userID]
+	}
+	extraShares, err := allocateByUser(extras, userIDs, weights)
+	if err != nil {
+		return nil, nil, &splitError{Message: err.Error()}
+	}
+
+	splits := make([]models.ExpenseSplit, len(userIDs))
+	for i, userID := range userIDs {
+		splits[i] = models.ExpenseSplit{
+			UserID:     userID,
+			AmountOwed: subtotals[userID] + extraShares[i],
+		}
+	}
+	return items, splits, nil
+}
+
+// checkItems reports every item that costs nothing or has no, unknown or
+// repeated participants.
+func checkItems(entries []itemEntry, members []models.GroupMember) []fieldError {
+	var fields []fieldError
+	for i, entry := range entries {
+		if entry.Amount <= 0 {
+			fields = append(fields, fieldError{
+				Field:   fmt.Sprintf("items[%d].amount", i),
+				Message: "Each item must cost more than 0",
+			})
+		}
+		if len(entry.Participants) == 0 {
+			fields = append(fields, fieldError{
+				Field:   fmt.Sprintf("items[%d].participants", i),
+				Message: "Each item needs at least one participant",
+			})
+		}
+		path := fmt.Sprintf("items[%d].participants", i) + "[%d]"
+		fields = append(fields, checkParticipants(path, entry.Participants, members)...)
+	}
+	return fields
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Page sizes for cursor-paginated listings.
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+)
+
+var errInvalidCursor = errors.New("Invalid cursor")
+
+// pageLimit reads ?limit=, defaulting to defaultPageLimit and capped at maxPageLimit.
+func pageLimit(c *gin.Context) (int, error) {
+	value := c.Query("limit")
+	if value == "" {
+		return defaultPageLimit, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 {
+		return 0, errors.New("limit must be a positive number")
+	}
+	return min(limit, maxPageLimit), nil
+}
+
+// encodeCursor turns the position of the last row on a page into an opaque
+// ?cursor= token. Clients must not rely on its contents.
+func encodeCursor(position any) string {
+	data, _ := json.Marshal(position)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor reads a token made by encodeCursor into position.
+func decodeCursor(token string, position any) error {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || json.Unmarshal(data, position) != nil {
+		return errInvalidCursor
+	}
+	return nil
+}
+
+// pageEnvelope is the "page" object returned next to a listing's rows.
+// next_cursor is empty on the last page.
+func pageEnvelope(limit int, hasMore bool, nextCursor string) gin.H {
+	return gin.H{
+		"limit":       limit,
+		"has_more":    hasMore,
+		"next_cursor": nextCursor,
+	}
+}
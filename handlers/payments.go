@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"net/http"
+	"splitwise-api/auth"
+	"splitwise-api/config"
+	"splitwise-api/models"
+	"splitwise-api/money"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RecordPayment — POST /groups/:id/payments
+// Records that from_user actually paid to_user (amount in paise).
+// from_user defaults to the authenticated caller when omitted.
+func RecordPayment(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	var input struct {
+		FromUser uint   `json:"from_user"`
+		ToUser   uint   `json:"to_user" binding:"required"`
+		Amount   int64  `json:"amount" binding:"required"` // in paise
+		Note     string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	callerID := auth.CurrentUserID(c)
+	if input.FromUser == 0 {
+		input.FromUser = callerID
+	}
+
+	// ── VALIDATION GUARDS ────────────────────────────────────────────────
+	if input.Amount <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Amount must be greater than 0"})
+		return
+	}
+
+	if input.FromUser == input.ToUser {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A payment needs two different members"})
+		return
+	}
+	// ─────────────────────────────────────────────────────────────────────
+
+	// Both sides must belong to the group
+	var count int64
+	config.DB.Model(&models.GroupMember{}).
+		Where("group_id = ? AND user_id IN ?", groupID, []uint{input.FromUser, input.ToUser}).
+		Count(&count)
+	if count != 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Both from_user and to_user must be members of this group"})
+		return
+	}
+
+	payment := models.Payment{
+		GroupID:   uint(groupID),
+		FromUser:  input.FromUser,
+		ToUser:    input.ToUser,
+		Amount:    input.Amount,
+		Note:      input.Note,
+		CreatedBy: callerID,
+	}
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&payment).Error; err != nil {
+			return err
+		}
+		return recordPaymentActivity(tx, models.ActivityPaymentRecorded, callerID, payment)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record payment"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Payment recorded successfully",
+		"payment": payment,
+	})
+}
+
+// GetPayments — GET /groups/:id/payments
+// Lists recorded payments in a group, newest first.
+func GetPayments(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	var payments []models.Payment
+	config.DB.Where("group_id = ?", groupID).Order("created_at DESC").Find(&payments)
+
+	c.JSON(http.StatusOK, gin.H{"payments": payments})
+}
+
+// DeletePayment — DELETE /payments/:id
+// Removes a payment recorded by mistake; balances revert accordingly.
+func DeletePayment(c *gin.Context) {
+	paymentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment ID"})
+		return
+	}
+
+	var payment models.Payment
+	if err := config.DB.First(&payment, paymentID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Payment not found"})
+		return
+	}
+
+	member := auth.CurrentMembership(c)
+	if !canModify(member, payment.CreatedBy, auth.PermModifyOwnExpense) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Your role in this group does not allow modifying this payment"})
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&payment).Error; err != nil {
+			return err
+		}
+		return recordPaymentActivity(tx, models.ActivityPaymentDeleted, member.UserID, payment)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete payment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Payment deleted successfully"})
+}
+
+// recordPaymentActivity records a change to a payment, described by who paid
+// whom how much.
+func recordPaymentActivity(tx *gorm.DB, kind string, actorID uint, p models.Payment) error {
+	var group models.Group
+	tx.Session(&gorm.Session{NewDB: true}).First(&group, p.GroupID)
+
+	return recordActivity(tx, models.Activity{
+		GroupID:   p.GroupID,
+		ActorID:   actorID,
+		Type:      kind,
+		PaymentID: idRef(p.ID),
+	}, gin.H{
+		"from_user":        p.FromUser,
+		"to_user":          p.ToUser,
+		"amount":           p.Amount,
+		"amount_formatted": money.Money{Amount: p.Amount, Currency: group.Currency}.String(),
+	})
+}
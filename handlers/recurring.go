@@ -0,0 +1,435 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"splitwise-api/auth"
+	"splitwise-api/config"
+	"splitwise-api/models"
+	"splitwise-api/search"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxCatchUp bounds how many missed occurrences of one template are created
+// per run, so a daily template started years ago cannot stall the scheduler.
+// The rest follow on the next runs.
+const maxCatchUp = 100
+
+// maxFailures is how many runs in a row a template may fail before it is
+// stopped instead of retried every minute forever.
+const maxFailures = 10
+
+// AddRecurringExpense — POST /groups/:id/recurring-expenses
+// Takes the same body as AddExpense plus the schedule:
+// frequency ("daily", "weekly", "monthly", "yearly"), interval (default 1),
+// start_date and an optional end_date ("2026-11-01" or RFC 3339). Dates are
+// read in the body's timezone (IANA, default UTC), which the calendar rules
+// keep using: a monthly rule at midnight in Asia/Kolkata stays there. A
+// date-only end_date includes that whole day.
+// The expense body is validated now, so a broken template is rejected up front.
+func AddRecurringExpense(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	var input struct {
+		expenseInput
+		Frequency string `json:"frequency"`
+		Interval  int    `json:"interval"`
+		StartDate string `json:"start_date"`
+		EndDate   string `json:"end_date"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var group models.Group
+	if err := config.DB.First(&group, groupID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+		return
+	}
+
+	switch input.Frequency {
+	case models.FrequencyDaily, models.FrequencyWeekly, models.FrequencyMonthly, models.FrequencyYearly:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "frequency must be one of: daily, weekly, monthly, yearly"})
+		return
+	}
+	if input.Interval == 0 {
+		input.Interval = 1
+	}
+	if input.Interval < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "interval must be at least 1"})
+		return
+	}
+
+	if input.Timezone == "" {
+		input.Timezone = "UTC"
+	}
+	loc, err := loadLocation(input.Timezone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	startDate, _, err := parseTime(input.StartDate, loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date: " + err.Error()})
+		return
+	}
+	startDate = startDate.UTC()
+	var endDate *time.Time
+	if input.EndDate != "" {
+		parsed, dateOnly, err := parseTime(input.EndDate, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date: " + err.Error()})
+			return
+		}
+		if dateOnly {
+			// Through the end of that day, like the expense list's ?to=
+			parsed = parsed.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+		if parsed.Before(startDate) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must not be before start_date"})
+			return
+		}
+		parsed = parsed.UTC()
+		endDate = &parsed
+	}
+	if input.ExpenseDate != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expense_date is set per occurrence; use start_date"})
+		return
+	}
+
+	callerID := auth.CurrentUserID(c)
+	if input.Currency == "" {
+		input.Currency = group.Currency
+	}
+
+	// Store the body as sent; it is re-validated on every occurrence
+	template, err := json.Marshal(input.expenseInput)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save recurring expense"})
+		return
+	}
+	if _, ok := prepareExpense(c, uint(groupID), callerID, &input.expenseInput); !ok {
+		return
+	}
+
+	recurring := models.RecurringExpense{
+		GroupID:     uint(groupID),
+		CreatedBy:   callerID,
+		Description: input.Description,
+		Frequency:   input.Frequency,
+		Interval:    input.Interval,
+		StartDate:   startDate,
+		EndDate:     endDate,
+		Timezone:    input.Timezone,
+		Template:    template,
+	}
+	recurring.NextRunAt = recurring.NextRun(0)
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&recurring).Error; err != nil {
+			return err
+		}
+		return recordActivity(tx, models.Activity{
+			GroupID: recurring.GroupID,
+			ActorID: callerID,
+			Type:    models.ActivityRecurringAdded,
+		}, gin.H{"recurring_expense_id": recurring.ID, "description": recurring.Description, "frequency": recurring.Frequency})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save recurring expense"})
+		return
+	}
+
+	// Occurrences already due (a start date in the past) are created right
+	// away instead of on the scheduler's next tick.
+	materializeDue(&recurring, time.Now())
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":           "Recurring expense added successfully",
+		"recurring_expense": recurring,
+	})
+}
+
+// GetRecurringExpenses — GET /groups/:id/recurring-expenses
+func GetRecurringExpenses(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	var recurring []models.RecurringExpense
+	config.DB.Where("group_id = ?", groupID).Find(&recurring)
+
+	c.JSON(http.StatusOK, gin.H{"recurring_expenses": recurring})
+}
+
+// DeleteRecurringExpense — DELETE /recurring-expenses/:id
+// Stops future occurrences. Expenses already created are kept.
+func DeleteRecurringExpense(c *gin.Context) {
+	recurringID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid recurring expense ID"})
+		return
+	}
+
+	var recurring models.RecurringExpense
+	if err := config.DB.First(&recurring, recurringID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recurring expense not found"})
+		return
+	}
+
+	member := auth.CurrentMembership(c)
+	if !canModify(member, recurring.CreatedBy, auth.PermModifyOwnExpense) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Your role in this group does not allow modifying this recurring expense"})
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&recurring).Error; err != nil {
+			return err
+		}
+		return recordActivity(tx, models.Activity{
+			GroupID: recurring.GroupID,
+			ActorID: member.UserID,
+			Type:    models.ActivityRecurringStopped,
+		}, gin.H{"recurring_expense_id": recurring.ID, "description": recurring.Description})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete recurring expense"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Recurring expense deleted successfully"})
+}
+
+// stopTemplatesWith stops the group's running templates that pay for or
+// split with userID, who is leaving the group: none of them could run again.
+// Like a template stopped after failing, each keeps its row with next_run_at
+// cleared and last_error saying why. It returns the stopped templates' IDs.
+func stopTemplatesWith(tx *gorm.DB, groupID, userID, actorID uint) ([]uint, error) {
+	var templates []models.RecurringExpense
+	if err := tx.Where("group_id = ? AND next_run_at IS NOT NULL", groupID).Find(&templates).Error; err != nil {
+		return nil, err
+	}
+
+	reason := "User " + strconv.FormatUint(uint64(userID), 10) + " left the group"
+	stopped := []uint{}
+	for _, r := range templates {
+		var input expenseInput
+		if err := json.Unmarshal(r.Template, &input); err != nil || !templateInvolves(input, r.CreatedBy, userID) {
+			continue // an unreadable template fails and stops on its own
+		}
+		if err := tx.Model(&models.RecurringExpense{}).Where("id = ?", r.ID).
+			Updates(map[string]interface{}{"next_run_at": nil, "last_error": reason}).Error; err != nil {
+			return nil, err
+		}
+		if err := recordActivity(tx, models.Activity{
+			GroupID: groupID,
+			ActorID: actorID,
+			Type:    models.ActivityRecurringStopped,
+		}, gin.H{"recurring_expense_id": r.ID, "description": r.Description, "reason": reason}); err != nil {
+			return nil, err
+		}
+		stopped = append(stopped, r.ID)
+	}
+	return stopped, nil
+}
+
+// templateInvolves reports whether a template's expense names userID as a
+// payer or split user. Without paid_by or payers, its creator pays.
+func templateInvolves(input expenseInput, createdBy, userID uint) bool {
+	payer := input.PaidBy
+	if payer == 0 && len(input.Payers) == 0 {
+		payer = createdBy
+	}
+	if payer == userID {
+		return true
+	}
+	for _, p := range input.Payers {
+		if p.UserID == userID {
+			return true
+		}
+	}
+	for _, s := range input.Splits {
+		if s.UserID == userID {
+			return true
+		}
+	}
+	participants := append([]uint{}, input.Participants...)
+	for _, item := range input.Items {
+		participants = append(participants, item.Participants...)
+	}
+	for _, id := range participants {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// MaterializeRecurringExpenses creates an Expense for every occurrence that
+// is due by now. It is run by the scheduler; after downtime it catches up on
+// all missed occurrences, oldest first.
+func MaterializeRecurringExpenses(now time.Time) error {
+	var due []models.RecurringExpense
+	if err := config.DB.Where("next_run_at <= ?", now).Find(&due).Error; err != nil {
+		return err
+	}
+
+	for i := range due {
+		materializeDue(&due[i], now)
+	}
+	return nil
+}
+
+var errOccurrenceTaken = errors.New("occurrence already created")
+
+// materializeDue creates the due occurrences of one template. A template
+// that no longer validates (e.g. its payer left the group) is logged and
+// retried on the next run, up to maxFailures runs in a row.
+func materializeDue(r *models.RecurringExpense, now time.Time) {
+	for i := 0; i < maxCatchUp && r.NextRunAt != nil && !r.NextRunAt.After(now); i++ {
+		err := materializeOccurrence(r)
+		if errors.Is(err, errOccurrenceTaken) {
+			// Someone else got there first: continue from their state
+			if err := config.DB.First(r, r.ID).Error; err != nil {
+				return
+			}
+			continue
+		}
+		if err != nil {
+			recordFailure(r, err)
+			return
+		}
+	}
+}
+
+// recordFailure counts a failed run of a template. After maxFailures in a
+// row the template is stopped: next_run_at is cleared, last_error keeps the
+// reason and the group's activity feed shows it.
+func recordFailure(r *models.RecurringExpense, cause error) {
+	failures := r.Failures + 1
+	stop := failures >= maxFailures
+	updates := map[string]interface{}{"failures": failures, "last_error": cause.Error()}
+	if stop {
+		updates["next_run_at"] = nil
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.RecurringExpense{}).Where("id = ?", r.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+		if !stop {
+			return nil
+		}
+		return recordActivity(tx, models.Activity{
+			GroupID: r.GroupID,
+			ActorID: r.CreatedBy,
+			Type:    models.ActivityRecurringStopped,
+		}, gin.H{"recurring_expense_id": r.ID, "description": r.Description, "reason": cause.Error()})
+	})
+	if err != nil {
+		log.Printf("recurring expense %d: %v (and failed to record it: %v)", r.ID, cause, err)
+		return
+	}
+
+	r.Failures, r.LastError = failures, cause.Error()
+	if stop {
+		r.NextRunAt = nil
+		log.Printf("recurring expense %d: stopped after %d failed runs: %v", r.ID, failures, cause)
+		return
+	}
+	log.Printf("recurring expense %d: %v", r.ID, cause)
+}
+
+// materializeOccurrence creates the expense for the template's next
+// occurrence and advances the template, in one transaction.
+//
+// Idempotency has two guards: the template only advances if its occurrence
+// count is unchanged, and (recurring_expense_id, occurrence) is unique on
+// expenses, so no occurrence can be created twice.
+func materializeOccurrence(r *models.RecurringExpense) error {
+	var input expenseInput
+	if err := json.Unmarshal(r.Template, &input); err != nil {
+		return err
+	}
+	rows, splitErr := buildExpense(r.GroupID, r.CreatedBy, &input)
+	if splitErr != nil {
+		return errors.New(splitErr.Message)
+	}
+
+	occurrence := r.Occurrences
+	dueAt := *r.NextRunAt
+	next := r.NextRun(occurrence + 1)
+	recurringID := r.ID
+
+	expense := models.Expense{
+		GroupID:            r.GroupID,
+		PaidBy:             input.PaidBy,
+		CreatedBy:          r.CreatedBy,
+		Amount:             input.Amount,
+		Currency:           input.Currency,
+		RateNum:            input.rate.num,
+		RateDen:            input.rate.den,
+		Description:        input.Description,
+		Notes:              input.Notes,
+		ExpenseDate:        dueAt,
+		SplitType:          input.SplitType,
+		CategoryID:         categoryRef(input.CategoryID),
+		Version:            1,
+		Tax:                input.Tax,
+		Tip:                input.Tip,
+		RecurringExpenseID: &recurringID,
+		Occurrence:         occurrence + 1,
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.RecurringExpense{}).
+			Where("id = ? AND occurrences = ?", r.ID, occurrence).
+			Updates(map[string]interface{}{
+				"occurrences": occurrence + 1,
+				"next_run_at": next,
+				"failures":    0,
+				"last_error":  "",
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errOccurrenceTaken
+		}
+
+		if err := tx.Create(&expense).Error; err != nil {
+			return err
+		}
+		if err := rows.create(tx, expense.ID); err != nil {
+			return err
+		}
+		if err := search.IndexExpense(tx, expense.ID); err != nil {
+			return err
+		}
+		return recordExpenseActivity(tx, models.ActivityExpenseAdded, r.CreatedBy, expense,
+			gin.H{"recurring_expense_id": r.ID, "occurrence": expense.Occurrence})
+	})
+	if err != nil {
+		return err
+	}
+
+	r.Occurrences, r.NextRunAt = occurrence+1, next
+	r.Failures, r.LastError = 0, ""
+	return nil
+}
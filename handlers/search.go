@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+	"splitwise-api/auth"
+	"splitwise-api/config"
+	"splitwise-api/models"
+	"splitwise-api/search"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchExpenses — GET /search?q=uber goa
+// Full-text search over the description, notes, category, receipt items and
+// comments of expenses in the caller's groups, best match first.
+// ?group_id= narrows it to one group; ?limit= caps the results (default 50).
+func SearchExpenses(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+
+	limit, err := pageLimit(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Only ever search groups the caller is a member of
+	memberships := config.DB.Model(&models.GroupMember{}).Where("user_id = ?", auth.CurrentUserID(c))
+	if value := c.Query("group_id"); value != "" {
+		groupID, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group_id"})
+			return
+		}
+		memberships = memberships.Where("group_id = ?", groupID)
+	}
+	var groupIDs []uint
+	memberships.Pluck("group_id", &groupIDs)
+
+	hits, err := search.Search(config.DB, groupIDs, q, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Search failed"})
+		return
+	}
+
+	ids := make([]uint, len(hits))
+	for i, h := range hits {
+		ids[i] = h.ExpenseID
+	}
+	var expenses []models.Expense
+	config.DB.Where("id IN ?", ids).Find(&expenses)
+	byID := make(map[uint]models.Expense, len(expenses))
+	for _, e := range expenses {
+		byID[e.ID] = e
+	}
+
+	// Keep the ranking order of the hits
+	results := []gin.H{}
+	for _, h := range hits {
+		expense, ok := byID[h.ExpenseID]
+		if !ok {
+			continue
+		}
+		results = append(results, gin.H{
+			"expense": expense,
+			"snippet": h.Snippet,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"query":   q,
+		"results": results,
+	})
+}
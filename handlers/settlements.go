@@ -1,13 +1,16 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 	"splitwise-api/algorithms"
 	"splitwise-api/config"
 	"splitwise-api/models"
+	"splitwise-api/money"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // GetBalances — GET /groups/:id/balances
@@ -26,7 +29,11 @@ func GetBalances(c *gin.Context) {
 		return
 	}
 
-	netBalances := computeNetBalances(uint(groupID))
+	netBalances, err := computeNetBalances(config.DB, group)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
 
 	// Fetch user names for readability
 	var result []gin.H
@@ -40,17 +47,19 @@ func GetBalances(c *gin.Context) {
 			status = "debtor"
 		}
 		result = append(result, gin.H{
-			"user_id": uid,
-			"name":    user.Name,
-			"balance": bal, // in paise
-			"status":  status,
+			"user_id":           uid,
+			"name":              user.Name,
+			"balance":           bal, // in minor units of the group currency
+			"balance_formatted": money.Money{Amount: bal, Currency: group.Currency}.String(),
+			"status":            status,
 		})
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"group_id": groupID,
+		"currency": group.Currency,
 		"balances": result,
-		"note":     "Amounts are in paise. Divide by 100 for INR.",
+		"note":     "Amounts are in minor units of the group currency (paise for INR).",
 	})
 }
 
@@ -69,7 +78,11 @@ func GetSettlements(c *gin.Context) {
 		return
 	}
 
-	netBalances := computeNetBalances(uint(groupID))
+	netBalances, err := computeNetBalances(config.DB, group)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
 	transactions := algorithms.MinimizeTransactions(netBalances)
 
 	// Enrich with user names
@@ -78,14 +91,22 @@ func GetSettlements(c *gin.Context) {
 		var from, to models.User
 		config.DB.First(&from, tx.From)
 		config.DB.First(&to, tx.To)
-		result = append(result, gin.H{
-			"from":         tx.From,
-			"from_name":    from.Name,
-			"to":           tx.To,
-			"to_name":      to.Name,
-			"amount_paise": tx.Amount,
-			"amount_inr":   formatINR(tx.Amount),
-		})
+		entry := gin.H{
+			"from":             tx.From,
+			"from_name":        from.Name,
+			"to":               tx.To,
+			"to_name":          to.Name,
+			"amount":           tx.Amount,
+			"currency":         group.Currency,
+			"amount_formatted": money.Money{Amount: tx.Amount, Currency: group.Currency}.String(),
+		}
+		// Deprecated fields from before multi-currency groups, kept so
+		// existing clients of INR groups keep working
+		if group.Currency == "INR" {
+			entry["amount_paise"] = tx.Amount
+			entry["amount_inr"] = entry["amount_formatted"]
+		}
+		result = append(result, entry)
 	}
 
 	if len(result) == 0 {
@@ -99,6 +120,7 @@ func GetSettlements(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"group_id":                groupID,
+		"currency":                group.Currency,
 		"transactions":            result,
 		"total_transaction_count": len(result),
 		"algorithm":               "Greedy minimization — O(n log n)",
@@ -106,40 +128,76 @@ func GetSettlements(c *gin.Context) {
 }
 
 // computeNetBalances calculates net balance per user for a group.
-// Net = total paid − total owed (in paise)
-func computeNetBalances(groupID uint) map[uint]int64 {
+// Net = total paid − total owed + payments sent − payments received,
+// in minor units of the group currency. Every payer of a multi-payer expense
+// is credited with their part. Expenses in other currencies are converted
+// with convertExpense at their own fixed rate; a missing rate is an error.
+// db is config.DB, or a transaction that must see the same balances as it
+// writes.
+func computeNetBalances(db *gorm.DB, group models.Group) (map[uint]int64, error) {
 	netBalances := make(map[uint]int64)
 
-	// Credit: what each user paid
+	// Preload skips soft-deleted rows, just like soft-deleted expenses
 	var expenses []models.Expense
-	config.DB.Where("group_id = ?", groupID).Find(&expenses)
+	db.Where("group_id = ?", group.ID).Preload("Splits").Preload("Payers").Find(&expenses)
 	for _, e := range expenses {
-		netBalances[e.PaidBy] += e.Amount
+		paid, owed, err := convertExpense(e, group.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("expense %d: %w", e.ID, err)
+		}
+
+		// Credit: what each payer paid
+		payerIDs, _ := expensePayers(e)
+		for i, userID := range payerIDs {
+			if err := addBalance(netBalances, userID, paid[i]); err != nil {
+				return nil, err
+			}
+		}
+
+		// Debit: what each user owes on this expense
+		for i, s := range e.Splits {
+			if err := addBalance(netBalances, s.UserID, -owed[i]); err != nil {
+				return nil, err
+			}
+		}
 	}
 
-	// Debit: what each user owes across all expense splits
-	var splits []models.ExpenseSplit
-	config.DB.
-		Joins("JOIN expenses ON expenses.id = expense_splits.expense_id").
-		Where("expenses.group_id = ? AND expenses.deleted_at IS NULL AND expense_splits.deleted_at IS NULL", groupID).
-		Find(&splits)
-	for _, s := range splits {
-		netBalances[s.UserID] -= s.AmountOwed
+	// Recorded payments: paying someone reduces your debt, receiving reduces your credit
+	var payments []models.Payment
+	db.Where("group_id = ?", group.ID).Find(&payments)
+	for _, p := range payments {
+		if err := addBalance(netBalances, p.FromUser, p.Amount); err != nil {
+			return nil, err
+		}
+		if err := addBalance(netBalances, p.ToUser, -p.Amount); err != nil {
+			return nil, err
+		}
 	}
 
-	return netBalances
+	return netBalances, nil
 }
 
-// formatINR converts paise (int64) to a readable INR string like "₹100.50"
-func formatINR(paise int64) string {
-	rupees := paise / 100
-	paiseRemainder := paise % 100
-	if paiseRemainder == 0 {
-		return "₹" + strconv.FormatInt(rupees, 10) + ".00"
+// expensePayers returns who paid how much on an expense: the ExpensePayment
+// rows of a multi-payer expense, or just PaidBy paying the full amount.
+func expensePayers(e models.Expense) ([]uint, []int64) {
+	if len(e.Payers) == 0 {
+		return []uint{e.PaidBy}, []int64{e.Amount}
 	}
-	paiseStr := strconv.FormatInt(paiseRemainder, 10)
-	if paiseRemainder < 10 {
-		paiseStr = "0" + paiseStr
+
+	userIDs := make([]uint, len(e.Payers))
+	amounts := make([]int64, len(e.Payers))
+	for i, p := range e.Payers {
+		userIDs[i], amounts[i] = p.UserID, p.Amount
+	}
+	return userIDs, amounts
+}
+
+// addBalance adds delta to a user's running balance, failing on int64 overflow.
+func addBalance(balances map[uint]int64, userID uint, delta int64) error {
+	sum, err := money.AddInt64(balances[userID], delta)
+	if err != nil {
+		return fmt.Errorf("balance of user %d: %w", userID, err)
 	}
-	return "₹" + strconv.FormatInt(rupees, 10) + "." + paiseStr
+	balances[userID] = sum
+	return nil
 }
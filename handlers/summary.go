@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"net/http"
+	"sort"
+	"splitwise-api/auth"
 	"splitwise-api/config"
 	"splitwise-api/models"
 	"strconv"
@@ -19,6 +21,12 @@ func GetUserSummary(c *gin.Context) {
 		return
 	}
 
+	// A user's financial position is private to them
+	if uint(userID) != auth.CurrentUserID(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only view your own summary"})
+		return
+	}
+
 	// Verify user exists
 	var user models.User
 	if err := config.DB.First(&user, userID).Error; err != nil {
@@ -30,37 +38,88 @@ func GetUserSummary(c *gin.Context) {
 	var memberships []models.GroupMember
 	config.DB.Where("user_id = ?", userID).Find(&memberships)
 
-	var totalOwedToUser int64 // user is creditor in these amounts
-	var totalUserOwes int64   // user is debtor in these amounts
+	// Groups may use different currencies, and amounts in different
+	// currencies are never added together — totals are kept per currency.
+	type currencyTotals struct {
+		owedToUser int64 // user is creditor in these amounts
+		userOwes   int64 // user is debtor in these amounts
+	}
+	totals := make(map[string]*currencyTotals)
+	var currencies []string
 
 	for _, m := range memberships {
+		var group models.Group
+		if err := config.DB.First(&group, m.GroupID).Error; err != nil {
+			continue
+		}
+
 		// Reuse existing balance function for each group
-		balances := computeNetBalances(m.GroupID)
+		balances, err := computeNetBalances(config.DB, group)
+		if err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "group_id": group.ID})
+			return
+		}
 		netInGroup := balances[uint(userID)]
 
+		t, ok := totals[group.Currency]
+		if !ok {
+			t = &currencyTotals{}
+			totals[group.Currency] = t
+			currencies = append(currencies, group.Currency)
+		}
 		if netInGroup > 0 {
-			totalOwedToUser += netInGroup
+			t.owedToUser += netInGroup
 		} else if netInGroup < 0 {
-			totalUserOwes += -netInGroup // store as positive
+			t.userOwes += -netInGroup // store as positive
 		}
 	}
 
-	netBalance := totalOwedToUser - totalUserOwes
+	sort.Strings(currencies)
+	result := []gin.H{}
+	for _, cur := range currencies {
+		t := totals[cur]
+		netBalance := t.owedToUser - t.userOwes
 
-	status := "settled"
-	if netBalance > 0 {
-		status = "creditor"
-	} else if netBalance < 0 {
-		status = "debtor"
+		result = append(result, gin.H{
+			"currency":           cur,
+			"total_owed_to_user": t.owedToUser,
+			"total_user_owes":    t.userOwes,
+			"net_balance":        netBalance,
+			"status":             balanceStatus(netBalance),
+		})
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"user_id":                  userID,
-		"name":                     user.Name,
-		"total_owed_to_user_paise": totalOwedToUser,
-		"total_user_owes_paise":    totalUserOwes,
-		"net_balance_paise":        netBalance,
-		"status":                   status,
-		"note":                     "Amounts are in paise. Divide by 100 for INR.",
-	})
+	response := gin.H{
+		"user_id":  userID,
+		"name":     user.Name,
+		"balances": result,
+		"note":     "Amounts are in minor units of each currency (paise for INR).",
+	}
+
+	// Deprecated top-level totals from before multi-currency groups. They are
+	// only meaningful, and only sent, when every balance is in INR.
+	if len(currencies) == 0 || (len(currencies) == 1 && currencies[0] == "INR") {
+		t, ok := totals["INR"]
+		if !ok {
+			t = &currencyTotals{}
+		}
+		netBalance := t.owedToUser - t.userOwes
+		response["total_owed_to_user_paise"] = t.owedToUser
+		response["total_user_owes_paise"] = t.userOwes
+		response["net_balance_paise"] = netBalance
+		response["status"] = balanceStatus(netBalance)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// balanceStatus describes a net balance: owed money, owing money or neither.
+func balanceStatus(net int64) string {
+	switch {
+	case net > 0:
+		return "creditor"
+	case net < 0:
+		return "debtor"
+	}
+	return "settled"
 }
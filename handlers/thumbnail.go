@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	_ "image/gif" // register decoders for image.Decode
+	"image/jpeg"
+	_ "image/png"
+)
+
+const (
+	thumbnailSize = 256 // longest side, in pixels
+
+	// Larger images are stored but get no thumbnail: decoding one would take
+	// width*height*4 bytes of memory however small the file is.
+	maxThumbnailSourcePixels = 50_000_000
+)
+
+// makeThumbnail scales a JPEG, PNG or GIF down to fit thumbnailSize and
+// encodes it as JPEG. ok is false for formats the standard library cannot
+// decode (WebP, PDF) and for oversized images.
+func makeThumbnail(data []byte) (thumb []byte, ok bool) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || cfg.Width == 0 || cfg.Height == 0 ||
+		int64(cfg.Width)*int64(cfg.Height) > maxThumbnailSourcePixels {
+		return nil, false
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, false
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, scaleDown(src, thumbnailSize), &jpeg.Options{Quality: 80}); err != nil {
+		return nil, false
+	}
+	return buf.Bytes(), true
+}
+
+// scaleDown fits src within size×size, keeping its aspect ratio. Each output
+// pixel averages a grid of samples from the source area it covers, which is
+// far smoother than nearest-neighbour without reading every source pixel.
+// Transparent areas come out white, as JPEG has no alpha.
+func scaleDown(src image.Image, size int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > size || h > size {
+		if w >= h {
+			w, h = size, max(1, h*size/b.Dx())
+		} else {
+			w, h = max(1, w*size/b.Dy()), size
+		}
+	}
+
+	const samples = 4 // per axis, per output pixel
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var r, g, bl, n uint32
+			for sy := 0; sy < samples; sy++ {
+				for sx := 0; sx < samples; sx++ {
+					px := b.Min.X + ((2*x*samples+2*sx+1)*b.Dx())/(2*w*samples)
+					py := b.Min.Y + ((2*y*samples+2*sy+1)*b.Dy())/(2*h*samples)
+					c := color.NRGBA64Model.Convert(src.At(px, py)).(color.NRGBA64)
+					// Blend onto white by alpha
+					a := uint32(c.A)
+					r += (uint32(c.R)*a + 0xffff*(0xffff-a)) / 0xffff
+					g += (uint32(c.G)*a + 0xffff*(0xffff-a)) / 0xffff
+					bl += (uint32(c.B)*a + 0xffff*(0xffff-a)) / 0xffff
+					n++
+				}
+			}
+			dst.Set(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(bl / n), A: 0xffff})
+		}
+	}
+	return dst
+}
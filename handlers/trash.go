@@ -0,0 +1,323 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"splitwise-api/auth"
+	"splitwise-api/config"
+	"splitwise-api/models"
+	"splitwise-api/search"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// trashedExpense is a deleted expense as listed by GetTrash. PurgeAt is when
+// the purge job will remove it for good; nil if the trash is kept forever.
+type trashedExpense struct {
+	models.Expense
+	PurgeAt *time.Time `json:"purge_at"`
+}
+
+// GetTrash — GET /groups/:id/expenses/trash
+// Lists the group's deleted expenses, most recently deleted first, with the
+// splits, payers and items they had when deleted.
+func GetTrash(c *gin.Context) {
+	groupID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return
+	}
+
+	unscoped := func(db *gorm.DB) *gorm.DB { return db.Unscoped() }
+	var expenses []models.Expense
+	config.DB.Unscoped().
+		Where("group_id = ? AND deleted_at IS NOT NULL", groupID).
+		Order("deleted_at DESC, id DESC").
+		Preload("Splits", unscoped).Preload("Payers", unscoped).
+		Preload("Items", unscoped).Preload("Items.Participants", unscoped).
+		Find(&expenses)
+
+	result := make([]trashedExpense, len(expenses))
+	for i, e := range expenses {
+		result[i] = trashedExpense{Expense: e}
+		if config.TrashRetention > 0 {
+			purgeAt := e.DeletedAt.Time.Add(config.TrashRetention).UTC()
+			result[i].PurgeAt = &purgeAt
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"expenses": result})
+}
+
+// RestoreExpense — POST /expenses/:id/restore
+// Brings a deleted expense back together with its splits, payers and items.
+// Restoring needs the same permission as deleting.
+func RestoreExpense(c *gin.Context) {
+	expenseID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid expense ID"})
+		return
+	}
+
+	var expense models.Expense
+	if err := config.DB.Unscoped().First(&expense, expenseID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Expense not found in trash"})
+		return
+	}
+
+	if !canModifyExpense(auth.CurrentMembership(c), expense) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Your role in this group does not allow modifying this expense"})
+		return
+	}
+
+	var conflict *splitError
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if conflict, err = checkRestorable(tx, expense); err != nil {
+			return err
+		}
+		if conflict != nil {
+			return errNotRestorable
+		}
+		if err := restoreExpenseRows(tx, expense.ID); err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.Expense{}).Where("id = ?", expense.ID).
+			Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		if err := search.IndexExpense(tx, expense.ID); err != nil {
+			return err
+		}
+		return recordExpenseActivity(tx, models.ActivityExpenseRestored, auth.CurrentUserID(c), expense, nil)
+	})
+	if conflict != nil {
+		c.JSON(http.StatusConflict, conflict.response())
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore expense"})
+		return
+	}
+
+	var restored models.Expense
+	config.DB.Preload("Splits").Preload("Payers").Preload("Items.Participants").First(&restored, expense.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Expense restored successfully",
+		"expense": restored,
+	})
+}
+
+var errNotRestorable = errors.New("expense cannot be restored")
+
+// checkRestorable runs the membership checks of buildExpense on the rows a
+// restore would bring back: a deleted expense must have splits, and its
+// payers and split users must still be members, or restoring it would give
+// someone who left the group a balance they cannot settle.
+func checkRestorable(tx *gorm.DB, expense models.Expense) (*splitError, error) {
+	db := tx.Unscoped().Session(&gorm.Session{})
+
+	var splits []models.ExpenseSplit
+	if err := db.Where("expense_id = ? AND deleted_at IS NOT NULL", expense.ID).Find(&splits).Error; err != nil {
+		return nil, err
+	}
+	if len(splits) == 0 {
+		return &splitError{Message: "Expense has no splits and cannot be restored"}, nil
+	}
+	var payers []models.ExpensePayment
+	if err := db.Where("expense_id = ? AND deleted_at IS NOT NULL", expense.ID).Find(&payers).Error; err != nil {
+		return nil, err
+	}
+
+	var members []models.GroupMember
+	if err := tx.Session(&gorm.Session{NewDB: true}).Where("group_id = ?", expense.GroupID).Find(&members).Error; err != nil {
+		return nil, err
+	}
+
+	var fields []fieldError
+	if len(payers) == 0 {
+		payerIsMember := false
+		for _, m := range members {
+			payerIsMember = payerIsMember || m.UserID == expense.PaidBy
+		}
+		if !payerIsMember {
+			fields = append(fields, fieldError{
+				Field:   "paid_by",
+				Message: "User " + strconv.FormatUint(uint64(expense.PaidBy), 10) + " is not a member of this group",
+			})
+		}
+	} else {
+		payerIDs := make([]uint, len(payers))
+		for i, p := range payers {
+			payerIDs[i] = p.UserID
+		}
+		fields = checkParticipants("payers[%d].user_id", payerIDs, members)
+	}
+	splitIDs := make([]uint, len(splits))
+	for i, s := range splits {
+		splitIDs[i] = s.UserID
+	}
+	fields = append(fields, checkParticipants("splits[%d].user_id", splitIDs, members)...)
+
+	if len(fields) > 0 {
+		return &splitError{Message: "Someone on this expense is no longer a member of the group", Fields: fields}, nil
+	}
+	return nil, nil
+}
+
+// restoreExpenseRows undoes deleteExpenseRows. Every soft-deleted child row
+// of an expense was deleted with it: edits remove replaced rows for good.
+func restoreExpenseRows(tx *gorm.DB, expenseID uint) error {
+	db := tx.Unscoped().Session(&gorm.Session{})
+
+	for _, model := range []interface{}{&models.ExpenseSplit{}, &models.ExpensePayment{}, &models.ExpenseItem{}} {
+		if err := db.Model(model).Where("expense_id = ? AND deleted_at IS NOT NULL", expenseID).
+			Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+	}
+
+	itemIDs := db.Model(&models.ExpenseItem{}).Select("id").Where("expense_id = ?", expenseID)
+	return db.Model(&models.ExpenseItemParticipant{}).
+		Where("expense_item_id IN (?) AND deleted_at IS NOT NULL", itemIDs).
+		Update("deleted_at", nil).Error
+}
+
+// PurgeTrash permanently removes expenses deleted longer than
+// config.TrashRetention ago, with everything attached to them: splits,
+// payers, items, revisions, comments and attachment files. Attachments
+// deleted on their own are purged on the same schedule. Run by the scheduler.
+func PurgeTrash(now time.Time) error {
+	if config.TrashRetention <= 0 {
+		return nil
+	}
+	// deleted_at is written by GORM in server local time and compared as text
+	cutoff := now.Add(-config.TrashRetention).Local()
+
+	purged := 0
+	for {
+		var ids []uint
+		if err := config.DB.Unscoped().Model(&models.Expense{}).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+			Order("id").Limit(100).Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			break
+		}
+		for _, id := range ids {
+			if err := purgeExpense(id, cutoff); err != nil {
+				return err
+			}
+			purged++
+		}
+	}
+
+	if purged > 0 {
+		log.Printf("trash: purged %d expenses deleted before %s", purged, cutoff.Format(time.RFC3339))
+	}
+
+	files, err := purgeAttachments(cutoff)
+	if err != nil {
+		return err
+	}
+	if files > 0 {
+		log.Printf("trash: purged %d attachments deleted before %s", files, cutoff.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// purgeAttachments removes attachments deleted on their own (from an
+// expense that was not trashed) before cutoff: rows first, then files.
+func purgeAttachments(cutoff time.Time) (int, error) {
+	purged := 0
+	for {
+		var attachments []models.ExpenseAttachment
+		if err := config.DB.Unscoped().
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+			Order("id").Limit(100).Find(&attachments).Error; err != nil {
+			return purged, err
+		}
+		if len(attachments) == 0 {
+			return purged, nil
+		}
+
+		ids := make([]uint, len(attachments))
+		for i, a := range attachments {
+			ids[i] = a.ID
+		}
+		if err := config.DB.Unscoped().Where("id IN ?", ids).Delete(&models.ExpenseAttachment{}).Error; err != nil {
+			return purged, err
+		}
+
+		for _, a := range attachments {
+			for _, key := range []string{a.StorageKey, a.ThumbnailKey} {
+				if key == "" {
+					continue
+				}
+				if err := config.Files.Delete(key); err != nil {
+					log.Printf("trash: failed to delete file %s: %v", key, err)
+				}
+			}
+		}
+		purged += len(attachments)
+	}
+}
+
+// purgeExpense removes one trashed expense for good, unless it was restored
+// (or deleted again more recently) since it was picked.
+func purgeExpense(expenseID uint, cutoff time.Time) error {
+	var files []string
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		db := tx.Unscoped().Session(&gorm.Session{})
+
+		result := db.Where("id = ? AND deleted_at IS NOT NULL AND deleted_at < ?", expenseID, cutoff).
+			Delete(&models.Expense{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		if err := deleteExpenseRows(db, expenseID); err != nil {
+			return err
+		}
+		if err := db.Where("expense_id = ?", expenseID).Delete(&models.ExpenseRevision{}).Error; err != nil {
+			return err
+		}
+		if err := db.Where("expense_id = ?", expenseID).Delete(&models.ExpenseComment{}).Error; err != nil {
+			return err
+		}
+
+		var attachments []models.ExpenseAttachment
+		if err := db.Where("expense_id = ?", expenseID).Find(&attachments).Error; err != nil {
+			return err
+		}
+		for _, a := range attachments {
+			files = append(files, a.StorageKey)
+			if a.ThumbnailKey != "" {
+				files = append(files, a.ThumbnailKey)
+			}
+		}
+		if err := db.Where("expense_id = ?", expenseID).Delete(&models.ExpenseAttachment{}).Error; err != nil {
+			return err
+		}
+
+		return search.RemoveExpense(tx, expenseID)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Files go only once the rows are gone; a failure leaves an orphan file,
+	// never a row pointing at nothing.
+	for _, key := range files {
+		if err := config.Files.Delete(key); err != nil {
+			log.Printf("trash: failed to delete file %s: %v", key, err)
+		}
+	}
+	return nil
+}
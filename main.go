@@ -1,14 +1,28 @@
 package main
 
 import (
+	"splitwise-api/auth"
 	"splitwise-api/config"
 	"splitwise-api/handlers"
+	"splitwise-api/scheduler"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
 	config.ConnectDatabase()
+	config.LoadExchangeRates()
+	config.ConnectStorage()
+	config.LoadTrashRetention()
+
+	// Background jobs: recurring expenses are created within a minute of
+	// falling due, and missed ones are caught up on at startup. Expenses
+	// deleted longer than TRASH_RETENTION_DAYS ago are purged hourly.
+	scheduler.Start(
+		scheduler.Job{Name: "recurring expenses", Every: time.Minute, Run: handlers.MaterializeRecurringExpenses},
+		scheduler.Job{Name: "trash purge", Every: time.Hour, Run: handlers.PurgeTrash},
+	)
 
 	r := gin.Default()
 
@@ -19,22 +33,80 @@ func main() {
 
 	// ── Phase 1: Auth ──────────────────────────────────────────
 	r.POST("/register", handlers.Register)
-	r.GET("/users", handlers.GetUsers)
-	r.GET("/users/:id/summary", handlers.GetUserSummary)
+	r.POST("/login", handlers.Login)
+	r.POST("/token/refresh", handlers.RefreshToken)
+
+	// Everything below requires "Authorization: Bearer <access_token>"
+	api := r.Group("", auth.RequireAuth())
+
+	api.POST("/logout", handlers.Logout)
+	api.POST("/logout/all", handlers.LogoutAll)
+	api.GET("/sessions", handlers.GetSessions)
+	api.DELETE("/sessions/:id", handlers.RevokeUserSession)
+
+	api.GET("/users", handlers.GetUsers)
+	api.GET("/users/:id/summary", handlers.GetUserSummary)
+	api.GET("/users/:id/activity", handlers.GetUserActivity)
+	api.GET("/search", handlers.SearchExpenses)
 
 	// ── Phase 2: Groups ────────────────────────────────────────
-	r.POST("/groups", handlers.CreateGroup)
-	r.POST("/groups/:id/members", handlers.AddMember)
-	r.GET("/groups/:id", handlers.GetGroup)
+	api.POST("/groups", handlers.CreateGroup)
+
+	// Every /groups/:id/* route is restricted to members of that group
+	group := api.Group("/groups/:id", auth.RequireGroupMember())
+	group.GET("", handlers.GetGroup)
+	group.PATCH("", auth.RequirePermission(auth.PermRenameGroup), handlers.RenameGroup)
+	group.DELETE("", auth.RequirePermission(auth.PermDeleteGroup), handlers.DeleteGroup)
+	group.POST("/members", auth.RequirePermission(auth.PermAddMember), handlers.AddMember)
+	group.DELETE("/members/:user_id", handlers.RemoveMember) // leaving needs no permission; checked in the handler
+	group.PUT("/members/:user_id/role", auth.RequirePermission(auth.PermManageRoles), handlers.UpdateMemberRole)
+	group.POST("/transfer-ownership", auth.RequirePermission(auth.PermTransferOwnership), handlers.TransferOwnership)
+	group.GET("/activity", handlers.GetGroupActivity)
 
 	// ── Phase 3: Expenses ──────────────────────────────────────
-	r.POST("/groups/:id/expenses", handlers.AddExpense)
-	r.GET("/groups/:id/expenses", handlers.GetExpenses)
-	r.DELETE("/expenses/:id", handlers.DeleteExpense)
+	group.POST("/expenses", auth.RequirePermission(auth.PermAddExpense), handlers.AddExpense)
+	group.GET("/expenses", handlers.GetExpenses)
+	group.GET("/expenses/trash", handlers.GetTrash)
+
+	// /expenses/:id/* routes are restricted to members of the expense's group
+	expense := api.Group("/expenses/:id", auth.RequireExpenseGroupMember())
+	expense.PUT("", handlers.UpdateExpense)
+	expense.DELETE("", handlers.DeleteExpense)
+	expense.GET("/history", handlers.GetExpenseHistory)
+	expense.POST("/comments", auth.RequirePermission(auth.PermComment), handlers.AddComment)
+	expense.GET("/comments", handlers.GetComments)
+	expense.DELETE("/comments/:comment_id", handlers.DeleteComment)
+	expense.POST("/attachments", auth.RequirePermission(auth.PermAddExpense), handlers.AddAttachment)
+	expense.GET("/attachments", handlers.GetAttachments)
+	expense.GET("/attachments/:attachment_id", handlers.DownloadAttachment)
+	expense.DELETE("/attachments/:attachment_id", handlers.DeleteAttachment)
+
+	// Soft-deleted expenses can be restored until the purge job removes them
+	trash := api.Group("/expenses/:id", auth.RequireDeletedExpenseGroupMember())
+	trash.POST("/restore", handlers.RestoreExpense)
+
+	group.GET("/categories", handlers.GetCategories)
+	group.POST("/categories", auth.RequirePermission(auth.PermAddCategory), handlers.AddCategory)
+	group.GET("/reports/categories", handlers.GetCategoryReport)
+
+	group.POST("/recurring-expenses", auth.RequirePermission(auth.PermAddExpense), handlers.AddRecurringExpense)
+	group.GET("/recurring-expenses", handlers.GetRecurringExpenses)
+
+	recurring := api.Group("/recurring-expenses/:id", auth.RequireRecurringExpenseGroupMember())
+	recurring.DELETE("", handlers.DeleteRecurringExpense)
 
 	// ── Phase 4 & 5: Balances & Settlements ────────────────────
-	r.GET("/groups/:id/balances", handlers.GetBalances)
-	r.GET("/groups/:id/settlements", handlers.GetSettlements)
+	group.GET("/balances", handlers.GetBalances)
+	group.GET("/settlements", handlers.GetSettlements)
+	group.POST("/payments", auth.RequirePermission(auth.PermRecordPayment), handlers.RecordPayment)
+	group.GET("/payments", handlers.GetPayments)
+
+	payment := api.Group("/payments/:id", auth.RequirePaymentGroupMember())
+	payment.DELETE("", handlers.DeletePayment)
+
+	// ── Currencies ─────────────────────────────────────────────
+	group.GET("/exchange-rates", handlers.GetExchangeRates)
+	group.POST("/exchange-rates", auth.RequirePermission(auth.PermExchangeRates), handlers.AddExchangeRate)
 
 	r.Run(":8080")
 }
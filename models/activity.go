@@ -0,0 +1,48 @@
+package models
+
+import (
+	"encoding/json"
+
+	"gorm.io/gorm"
+)
+
+// Activity types: one per kind of change shown in the feed.
+const (
+	ActivityGroupCreated         = "group_created"
+	ActivityGroupRenamed         = "group_renamed"
+	ActivityGroupDeleted         = "group_deleted"
+	ActivityMemberJoined         = "member_joined"
+	ActivityMemberLeft           = "member_left"
+	ActivityMemberRemoved        = "member_removed"
+	ActivityMemberRoleChanged    = "member_role_changed"
+	ActivityOwnershipTransferred = "ownership_transferred"
+	ActivityExpenseAdded         = "expense_added"
+	ActivityExpenseEdited        = "expense_edited"
+	ActivityExpenseDeleted       = "expense_deleted"
+	ActivityExpenseRestored      = "expense_restored"
+	ActivityCommentAdded         = "comment_added"
+	ActivityCommentDeleted       = "comment_deleted"
+	ActivityAttachmentAdded      = "attachment_added"
+	ActivityAttachmentDeleted    = "attachment_deleted"
+	ActivityPaymentRecorded      = "payment_recorded"
+	ActivityPaymentDeleted       = "payment_deleted"
+	ActivityCategoryAdded        = "category_added"
+	ActivityRecurringAdded       = "recurring_expense_added"
+	ActivityRecurringStopped     = "recurring_expense_stopped"
+)
+
+// Activity is one entry of a group's activity feed: who changed what, and
+// when. ExpenseID, PaymentID and UserID point at what changed, where that
+// applies. Details is a small JSON object describing the change (e.g., the
+// description and amount, or the old and new name) as it was at the time,
+// so the feed still reads correctly after the subject is edited or deleted.
+type Activity struct {
+	gorm.Model
+	GroupID   uint            `json:"group_id" gorm:"not null;index"`
+	ActorID   uint            `json:"actor_id" gorm:"not null"` // who made the change
+	Type      string          `json:"type" gorm:"not null"`
+	ExpenseID *uint           `json:"expense_id,omitempty"`
+	PaymentID *uint           `json:"payment_id,omitempty"`
+	UserID    *uint           `json:"user_id,omitempty"` // member affected: joined, left, role changed, new owner
+	Details   json.RawMessage `json:"details,omitempty"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "gorm.io/gorm"
+
+// ExpenseAttachment is an uploaded proof of purchase (receipt photo or PDF).
+// The file itself lives in the attachment store under StorageKey; images
+// also get a small JPEG thumbnail under ThumbnailKey.
+type ExpenseAttachment struct {
+	gorm.Model
+	ExpenseID    uint   `json:"expense_id" gorm:"not null;index"`
+	UploadedBy   uint   `json:"uploaded_by" gorm:"not null"`
+	FileName     string `json:"file_name"`                    // as uploaded, for downloads
+	ContentType  string `json:"content_type" gorm:"not null"` // sniffed from the content, not the client's header
+	Size         int64  `json:"size" gorm:"not null"`         // in bytes
+	HasThumbnail bool   `json:"has_thumbnail"`
+	StorageKey   string `json:"-" gorm:"not null"`
+	ThumbnailKey string `json:"-"`
+}
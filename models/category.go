@@ -0,0 +1,18 @@
+package models
+
+import "gorm.io/gorm"
+
+// Category labels what an expense was for (food, rent…). Built-in categories
+// have no GroupID and are available to every group; custom categories belong
+// to a single group.
+type Category struct {
+	gorm.Model
+	GroupID *uint  `json:"group_id" gorm:"index"` // nil for built-in categories
+	Name    string `json:"name" gorm:"not null"`
+}
+
+// DefaultCategories are the built-in categories seeded at startup.
+var DefaultCategories = []string{
+	"food", "groceries", "travel", "transport", "rent",
+	"utilities", "entertainment", "shopping", "health", "other",
+}
@@ -0,0 +1,12 @@
+package models
+
+import "gorm.io/gorm"
+
+// ExpenseComment is a message in the discussion thread of an expense
+// ("did this include the tip?"). Deleted comments are soft-deleted.
+type ExpenseComment struct {
+	gorm.Model
+	ExpenseID uint   `json:"expense_id" gorm:"not null;index"`
+	UserID    uint   `json:"user_id" gorm:"not null"` // author
+	Body      string `json:"body" gorm:"not null"`
+}
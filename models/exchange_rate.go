@@ -0,0 +1,22 @@
+package models
+
+import "gorm.io/gorm"
+
+// ExchangeRate says that 1 unit of FromCurrency buys Rate units of ToCurrency.
+// Rate is kept exactly as entered (e.g. "83.25") and also as the reduced
+// fraction RateNum/RateDen used for integer-only conversion.
+// Rates entered through the API belong to one group (GroupID) and are only
+// used by it; rates imported from EXCHANGE_RATES_FILE have no group and are
+// shared by all. A group's own rate wins over a shared one, and within each
+// the most recently updated rate for a pair wins.
+type ExchangeRate struct {
+	gorm.Model
+	GroupID      *uint  `json:"group_id" gorm:"index"` // nil = shared, from the rates file
+	FromCurrency string `json:"from_currency" gorm:"not null;index:idx_exchange_rate_pair"`
+	ToCurrency   string `json:"to_currency" gorm:"not null;index:idx_exchange_rate_pair"`
+	Rate         string `json:"rate" gorm:"not null"`
+	RateNum      int64  `json:"-" gorm:"not null"`
+	RateDen      int64  `json:"-" gorm:"not null"`
+	Source       string `json:"source"` // "user" or "file"
+	CreatedBy    uint   `json:"created_by,omitempty"`
+}
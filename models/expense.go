@@ -1,17 +1,48 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"encoding/json"
+	"time"
 
-// Expense represents a shared expense paid by one member of a group.
+	"gorm.io/gorm"
+)
+
+// Expense represents a shared expense paid by one member of a group,
+// or by several when Payers is set (PaidBy is then the first payer).
 // Amount is stored in paise (int64) to avoid float precision errors.
 // Example: ₹100.50 = 10050 paise
+// For expenses in another currency than the group's, Amount and split
+// amounts are in the minor unit of Currency (cents for USD) and are converted
+// to the group currency when balances are computed, at the rate fixed on the
+// expense (RateNum/RateDen) when it was recorded or edited.
 type Expense struct {
 	gorm.Model
-	GroupID     uint           `json:"group_id" gorm:"not null"`
-	PaidBy      uint           `json:"paid_by" gorm:"not null"`
-	Amount      int64          `json:"amount" gorm:"not null"` // in paise
-	Description string         `json:"description"`
-	Splits      []ExpenseSplit `json:"splits,omitempty" gorm:"foreignKey:ExpenseID"`
+	GroupID     uint             `json:"group_id" gorm:"not null"`
+	PaidBy      uint             `json:"paid_by" gorm:"not null"`
+	CreatedBy   uint             `json:"created_by"`             // user who recorded the expense
+	Amount      int64            `json:"amount" gorm:"not null"` // in paise
+	Currency    string           `json:"currency" gorm:"not null;default:INR"`
+	RateNum     int64            `json:"rate_num,omitempty" gorm:"not null;default:0"` // to the group currency: 1 unit = RateNum/RateDen
+	RateDen     int64            `json:"rate_den,omitempty" gorm:"not null;default:0"` // 0/0 when Currency is the group currency
+	Description string           `json:"description"`
+	Notes       string           `json:"notes,omitempty"`           // free-form details, searchable
+	ExpenseDate time.Time        `json:"expense_date" gorm:"index"` // when it was spent (UTC); CreatedAt is when it was recorded
+	SplitType   string           `json:"split_type"`
+	CategoryID  *uint            `json:"category_id" gorm:"index"`          // nil = uncategorized
+	Version     int              `json:"version" gorm:"not null;default:1"` // bumped on every edit
+	Tax         int64            `json:"tax,omitempty"`                     // itemized only, in paise
+	Tip         int64            `json:"tip,omitempty"`                     // itemized only: tip or service charge, in paise
+	Splits      []ExpenseSplit   `json:"splits,omitempty" gorm:"foreignKey:ExpenseID"`
+	Payers      []ExpensePayment `json:"payers,omitempty" gorm:"foreignKey:ExpenseID"` // only for multi-payer expenses
+	Items       []ExpenseItem    `json:"items,omitempty" gorm:"foreignKey:ExpenseID"`  // only for itemized expenses
+
+	// Receipt photos and other proof of purchase
+	Attachments []ExpenseAttachment `json:"attachments,omitempty" gorm:"foreignKey:ExpenseID"`
+
+	// Set on expenses created from a RecurringExpense (Occurrence counts from
+	// 1); the pair is unique so an occurrence can never be created twice.
+	RecurringExpenseID *uint `json:"recurring_expense_id,omitempty" gorm:"uniqueIndex:idx_expense_occurrence"`
+	Occurrence         int   `json:"occurrence,omitempty" gorm:"uniqueIndex:idx_expense_occurrence"`
 }
 
 // ExpenseSplit records how much each member owes for a given expense.
@@ -22,3 +53,44 @@ type ExpenseSplit struct {
 	UserID     uint  `json:"user_id" gorm:"not null"`
 	AmountOwed int64 `json:"amount_owed" gorm:"not null"` // in paise
 }
+
+// ExpensePayment records how much one payer contributed to a multi-payer
+// expense (e.g., a hotel bill paid partly on two cards). The amounts sum to
+// Expense.Amount. Single-payer expenses have no rows and use Expense.PaidBy.
+type ExpensePayment struct {
+	gorm.Model
+	ExpenseID uint  `json:"expense_id" gorm:"not null;index"`
+	UserID    uint  `json:"user_id" gorm:"not null"`
+	Amount    int64 `json:"amount" gorm:"not null"` // in paise
+}
+
+// ExpenseItem is one line of an itemized receipt (e.g., "Paneer tikka ₹320"),
+// shared equally by its participants. Items plus Expense.Tax and Expense.Tip
+// sum to Expense.Amount.
+type ExpenseItem struct {
+	gorm.Model
+	ExpenseID    uint                     `json:"expense_id" gorm:"not null;index"`
+	Description  string                   `json:"description"`
+	Amount       int64                    `json:"amount" gorm:"not null"` // in paise
+	Participants []ExpenseItemParticipant `json:"participants" gorm:"foreignKey:ExpenseItemID"`
+}
+
+// ExpenseItemParticipant is a member who consumed an item. AmountOwed is
+// their equal part of the item alone; their share of tax and tip is only in
+// the derived ExpenseSplit.
+type ExpenseItemParticipant struct {
+	gorm.Model
+	ExpenseItemID uint  `json:"expense_item_id" gorm:"not null;index"`
+	UserID        uint  `json:"user_id" gorm:"not null"`
+	AmountOwed    int64 `json:"amount_owed" gorm:"not null"` // in paise
+}
+
+// ExpenseRevision is a snapshot of an expense (with its child rows) as it was
+// before an edit. Version is the version number of that snapshot.
+type ExpenseRevision struct {
+	gorm.Model
+	ExpenseID uint            `json:"expense_id" gorm:"not null;index"`
+	Version   int             `json:"version" gorm:"not null"`
+	EditedBy  uint            `json:"edited_by"`
+	Snapshot  json.RawMessage `json:"snapshot"` // the Expense as JSON
+}
@@ -3,16 +3,29 @@ package models
 import "gorm.io/gorm"
 
 // Group represents a shared expense group (e.g., roommates, trip)
+// Currency is the ISO 4217 code all balances and settlements are expressed in.
 type Group struct {
 	gorm.Model
 	Name      string        `json:"name" gorm:"not null"`
+	Currency  string        `json:"currency" gorm:"not null;default:INR"`
 	CreatedBy uint          `json:"created_by"`
 	Members   []GroupMember `json:"members,omitempty" gorm:"foreignKey:GroupID"`
 }
 
+// Membership roles, from most to least privileged.
+// What each role may do is defined in auth/permissions.go.
+const (
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+	RoleViewer = "viewer"
+)
+
 // GroupMember is the many-to-many join table between Group and User
+// Each group has exactly one owner.
 type GroupMember struct {
 	gorm.Model
-	GroupID uint `json:"group_id" gorm:"not null"`
-	UserID  uint `json:"user_id" gorm:"not null"`
+	GroupID uint   `json:"group_id" gorm:"not null"`
+	UserID  uint   `json:"user_id" gorm:"not null"`
+	Role    string `json:"role" gorm:"not null;default:member"`
 }
@@ -0,0 +1,17 @@
+package models
+
+import "gorm.io/gorm"
+
+// Payment records money that actually changed hands between two group
+// members ("settle up"). It moves both balances toward zero: FromUser's
+// debt and ToUser's credit each shrink by Amount.
+// Amount is stored in paise (int64).
+type Payment struct {
+	gorm.Model
+	GroupID   uint   `json:"group_id" gorm:"not null;index"`
+	FromUser  uint   `json:"from_user" gorm:"not null"`
+	ToUser    uint   `json:"to_user" gorm:"not null"`
+	Amount    int64  `json:"amount" gorm:"not null"` // in paise
+	Note      string `json:"note"`
+	CreatedBy uint   `json:"created_by"` // user who recorded the payment
+}
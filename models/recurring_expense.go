@@ -0,0 +1,77 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Recurrence frequencies. A RecurringExpense repeats every Interval of these.
+const (
+	FrequencyDaily   = "daily"
+	FrequencyWeekly  = "weekly"
+	FrequencyMonthly = "monthly"
+	FrequencyYearly  = "yearly"
+)
+
+// RecurringExpense is a template (e.g., rent on the 1st of every month) that
+// the scheduler turns into a real Expense on each occurrence.
+// Template is the expense request body: amount, split_type, splits, payers…
+// Occurrences counts the expenses created so far; NextRunAt is when the next
+// one is due, or nil once EndDate has passed or the template was stopped
+// after failing too often (LastError says why). Times are stored in UTC; the
+// calendar rules are applied in Timezone, so "the 1st at midnight" stays
+// midnight local time.
+type RecurringExpense struct {
+	gorm.Model
+	GroupID     uint            `json:"group_id" gorm:"not null;index"`
+	CreatedBy   uint            `json:"created_by"`
+	Description string          `json:"description"`
+	Frequency   string          `json:"frequency" gorm:"not null"`
+	Interval    int             `json:"interval" gorm:"not null;default:1"`   // every N days/weeks/months/years
+	StartDate   time.Time       `json:"start_date" gorm:"not null"`           // first occurrence
+	EndDate     *time.Time      `json:"end_date"`                             // nil = no end
+	Timezone    string          `json:"timezone" gorm:"not null;default:UTC"` // IANA name
+	Occurrences int             `json:"occurrences" gorm:"not null;default:0"`
+	NextRunAt   *time.Time      `json:"next_run_at" gorm:"index"`
+	Failures    int             `json:"failures" gorm:"not null;default:0"` // failed runs in a row
+	LastError   string          `json:"last_error,omitempty"`               // why the last run failed
+	Template    json.RawMessage `json:"template"`
+}
+
+// OccurrenceAt returns when occurrence n (0 = StartDate) falls due.
+// Monthly and yearly rules keep the start's day of month, clamped to the
+// month's last day: a rule starting Jan 31 runs Feb 28, Mar 31, Apr 30…
+func (r RecurringExpense) OccurrenceAt(n int) time.Time {
+	loc, err := time.LoadLocation(r.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	start := r.StartDate.In(loc)
+
+	switch r.Frequency {
+	case FrequencyDaily:
+		return start.AddDate(0, 0, n*r.Interval).UTC()
+	case FrequencyWeekly:
+		return start.AddDate(0, 0, 7*n*r.Interval).UTC()
+	}
+
+	months := n * r.Interval
+	if r.Frequency == FrequencyYearly {
+		months *= 12
+	}
+	first := time.Date(start.Year(), start.Month()+time.Month(months), 1,
+		start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(start.Day(), lastDay)-1).UTC()
+}
+
+// NextRun returns when occurrence n falls due, or nil if that is after EndDate.
+func (r RecurringExpense) NextRun(n int) *time.Time {
+	at := r.OccurrenceAt(n)
+	if r.EndDate != nil && at.After(*r.EndDate) {
+		return nil
+	}
+	return &at
+}
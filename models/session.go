@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Session is one signed-in device. Access tokens carry the session ID so a
+// session can be revoked server-side without rotating the signing key.
+// Only the SHA-256 hash of the refresh token is stored, never the token itself.
+type Session struct {
+	gorm.Model
+	UserID           uint       `json:"user_id" gorm:"not null;index"`
+	RefreshTokenHash string     `json:"-" gorm:"uniqueIndex;not null"`
+	UserAgent        string     `json:"user_agent"`
+	IPAddress        string     `json:"ip_address"`
+	ExpiresAt        time.Time  `json:"expires_at"`
+	LastUsedAt       time.Time  `json:"last_used_at"`
+	RevokedAt        *time.Time `json:"revoked_at"`
+}
+
+// Active reports whether the session can still be used at time now.
+func (s Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// RetiredRefreshToken is the hash of a refresh token that was rotated out.
+// Presenting one again means the token was copied, so its session is revoked.
+type RetiredRefreshToken struct {
+	ID        uint   `gorm:"primaryKey"`
+	SessionID uint   `gorm:"not null;index"`
+	TokenHash string `gorm:"uniqueIndex;not null"`
+	CreatedAt time.Time
+}
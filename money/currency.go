@@ -0,0 +1,56 @@
+package money
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrUnknownCurrency = errors.New("unknown or unsupported ISO 4217 currency code")
+
+// currency describes how an ISO 4217 currency is stored and displayed.
+type currency struct {
+	exponent int    // number of minor-unit digits: INR 2 (paise), JPY 0, KWD 3 (fils)
+	symbol   string // display prefix; empty means "<CODE> "
+}
+
+// currencies lists the supported ISO 4217 currencies.
+// Exponents follow the ISO 4217 minor-unit column.
+var currencies = map[string]currency{
+	// Exponent 2
+	"AED": {2, ""}, "AUD": {2, "A$"}, "BDT": {2, "৳"}, "BRL": {2, "R$"},
+	"CAD": {2, "C$"}, "CHF": {2, ""}, "CNY": {2, "CN¥"}, "CZK": {2, ""},
+	"DKK": {2, ""}, "EGP": {2, ""}, "EUR": {2, "€"}, "GBP": {2, "£"},
+	"HKD": {2, "HK$"}, "IDR": {2, ""}, "ILS": {2, "₪"}, "INR": {2, "₹"},
+	"LKR": {2, ""}, "MXN": {2, "MX$"}, "MYR": {2, ""}, "NOK": {2, ""},
+	"NPR": {2, ""}, "NZD": {2, "NZ$"}, "PHP": {2, "₱"}, "PKR": {2, ""},
+	"PLN": {2, ""}, "QAR": {2, ""}, "SAR": {2, ""}, "SEK": {2, ""},
+	"SGD": {2, "S$"}, "THB": {2, "฿"}, "TRY": {2, "₺"}, "TWD": {2, "NT$"},
+	"USD": {2, "$"}, "ZAR": {2, ""},
+
+	// Exponent 0 — no minor unit
+	"CLP": {0, ""}, "ISK": {0, ""}, "JPY": {0, "¥"}, "KRW": {0, "₩"},
+	"PYG": {0, ""}, "UGX": {0, ""}, "VND": {0, "₫"}, "XAF": {0, ""},
+	"XOF": {0, ""},
+
+	// Exponent 3
+	"BHD": {3, ""}, "IQD": {3, ""}, "JOD": {3, ""}, "KWD": {3, ""},
+	"LYD": {3, ""}, "OMR": {3, ""}, "TND": {3, ""},
+}
+
+// NormalizeCurrency upper-cases a currency code and reports whether it is a
+// supported ISO 4217 code.
+func NormalizeCurrency(code string) (string, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	_, ok := currencies[code]
+	return code, ok
+}
+
+// Exponent returns the number of minor-unit digits of a currency
+// (2 for INR: 1 rupee = 100 paise).
+func Exponent(code string) (int, error) {
+	cur, ok := currencies[code]
+	if !ok {
+		return 0, ErrUnknownCurrency
+	}
+	return cur.exponent, nil
+}
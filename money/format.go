@@ -0,0 +1,108 @@
+package money
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+)
+
+var (
+	ErrInvalidAmount = errors.New("amount must be a decimal like \"100.50\"")
+	ErrInvalidRate   = errors.New("rate must be a positive decimal like \"83.25\"")
+)
+
+// String formats m with its currency symbol and exactly Exponent digits:
+// "₹100.50", "¥1200", "KWD 1.250", "-$3.07".
+func (m Money) String() string {
+	cur, ok := currencies[m.Currency]
+	if !ok {
+		cur = currency{exponent: 2}
+	}
+	prefix := cur.symbol
+	if prefix == "" {
+		prefix = m.Currency + " "
+	}
+
+	sign := ""
+	if m.Amount < 0 {
+		sign = "-"
+	}
+	return sign + prefix + FormatDecimal(m.Amount, cur.exponent)
+}
+
+// FormatDecimal renders minor units as an unsigned decimal string with
+// exponent fractional digits: FormatDecimal(10050, 2) = "100.50".
+func FormatDecimal(minor int64, exponent int) string {
+	digits := new(big.Int).Abs(big.NewInt(minor)).String()
+	if exponent == 0 {
+		return digits
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	split := len(digits) - exponent
+	return digits[:split] + "." + digits[split:]
+}
+
+// Parse reads a decimal string in major units ("100.50", "-3", "0.5") as
+// Money in the given currency. More fractional digits than the currency's
+// exponent are rejected rather than rounded: "1.005" is not a valid INR amount.
+func Parse(s, currencyCode string) (Money, error) {
+	code, ok := NormalizeCurrency(currencyCode)
+	if !ok {
+		return Money{}, ErrUnknownCurrency
+	}
+	exponent := currencies[code].exponent
+
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	whole, frac, hasPoint := strings.Cut(s, ".")
+	if whole == "" || !isDigits(whole) || (hasPoint && (frac == "" || !isDigits(frac))) {
+		return Money{}, ErrInvalidAmount
+	}
+	if len(frac) > exponent {
+		return Money{}, errors.New("too many decimal places for " + code)
+	}
+	frac += strings.Repeat("0", exponent-len(frac))
+
+	value, ok := new(big.Int).SetString(whole+frac, 10)
+	if !ok {
+		return Money{}, ErrInvalidAmount
+	}
+	if negative {
+		value.Neg(value)
+	}
+	if !value.IsInt64() {
+		return Money{}, ErrOverflow
+	}
+	return Money{Amount: value.Int64(), Currency: code}, nil
+}
+
+// ParseRate parses a positive decimal exchange rate such as "83.2745" into an
+// exact fraction num/den (832745/10000, reduced). Rates are kept as fractions
+// rather than floats so that conversions are reproducible to the last paisa
+// and the inverse rate (den/num) is exact too.
+func ParseRate(s string) (num, den int64, err error) {
+	s = strings.TrimSpace(s)
+	whole, frac, hasPoint := strings.Cut(s, ".")
+	if whole == "" || !isDigits(whole) || (hasPoint && (frac == "" || !isDigits(frac))) {
+		return 0, 0, ErrInvalidRate
+	}
+
+	r, ok := new(big.Rat).SetString(s)
+	if !ok || r.Sign() <= 0 || !r.Num().IsInt64() || !r.Denom().IsInt64() {
+		return 0, 0, ErrInvalidRate
+	}
+	return r.Num().Int64(), r.Denom().Int64(), nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
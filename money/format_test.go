@@ -0,0 +1,125 @@
+package money
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in       string
+		currency string
+		want     Money
+	}{
+		{"100.50", "INR", Money{10050, "INR"}},
+		{"100.5", "INR", Money{10050, "INR"}},
+		{"100", "INR", Money{10000, "INR"}},
+		{"0.05", "inr", Money{5, "INR"}},
+		{" -3.07 ", "USD", Money{-307, "USD"}},
+		{"1200", "JPY", Money{1200, "JPY"}},
+		{"1.250", "KWD", Money{1250, "KWD"}},
+		{"0.001", "KWD", Money{1, "KWD"}},
+		{"92233720368547758.07", "INR", Money{math.MaxInt64, "INR"}},
+		{"-92233720368547758.08", "INR", Money{math.MinInt64, "INR"}},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in, tt.currency)
+		if err != nil || got != tt.want {
+			t.Errorf("Parse(%q, %s) = %v, %v; want %v", tt.in, tt.currency, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		in       string
+		currency string
+		want     error // nil: any error
+	}{
+		{"1.005", "INR", nil}, // too precise: never rounded
+		{"1.5", "JPY", nil},
+		{"1.2345", "KWD", nil},
+		{"", "INR", ErrInvalidAmount},
+		{"abc", "INR", ErrInvalidAmount},
+		{"1.", "INR", ErrInvalidAmount},
+		{".5", "INR", ErrInvalidAmount},
+		{"1e3", "INR", ErrInvalidAmount},
+		{"+1", "INR", ErrInvalidAmount},
+		{"1,000", "INR", ErrInvalidAmount},
+		{"--1", "INR", ErrInvalidAmount},
+		{"92233720368547758.08", "INR", ErrOverflow},
+		{"9223372036854775808", "JPY", ErrOverflow},
+		{"1", "XXX", ErrUnknownCurrency},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in, tt.currency)
+		if err == nil || (tt.want != nil && !errors.Is(err, tt.want)) {
+			t.Errorf("Parse(%q, %s) = %v, %v; want error %v", tt.in, tt.currency, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		in       string
+		num, den int64
+	}{
+		{"83.25", 333, 4},
+		{"83.2745", 166549, 2000},
+		{"0.011", 11, 1000},
+		{"1", 1, 1},
+		{"150", 150, 1},
+		{" 2.50 ", 5, 2},
+	}
+	for _, tt := range tests {
+		num, den, err := ParseRate(tt.in)
+		if err != nil || num != tt.num || den != tt.den {
+			t.Errorf("ParseRate(%q) = %d/%d, %v; want %d/%d", tt.in, num, den, err, tt.num, tt.den)
+		}
+	}
+
+	for _, in := range []string{"", "0", "0.000", "-1", "1/2", "1e2", ".5", "abc", "99999999999999999999"} {
+		if num, den, err := ParseRate(in); !errors.Is(err, ErrInvalidRate) {
+			t.Errorf("ParseRate(%q) = %d/%d, %v; want ErrInvalidRate", in, num, den, err)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		m    Money
+		want string
+	}{
+		{Money{10050, "INR"}, "₹100.50"},
+		{Money{5, "INR"}, "₹0.05"},
+		{Money{0, "INR"}, "₹0.00"},
+		{Money{-307, "USD"}, "-$3.07"},
+		{Money{1200, "JPY"}, "¥1200"},
+		{Money{1250, "KWD"}, "KWD 1.250"},
+		{Money{1, "KWD"}, "KWD 0.001"},
+		{Money{math.MinInt64, "INR"}, "-₹92233720368547758.08"},
+	}
+	for _, tt := range tests {
+		if got := tt.m.String(); got != tt.want {
+			t.Errorf("%#v.String() = %q, want %q", tt.m, got, tt.want)
+		}
+	}
+}
+
+// Every amount printed with String and parsed back must be unchanged.
+func TestParseRoundTrip(t *testing.T) {
+	for _, code := range []string{"INR", "JPY", "KWD"} {
+		exponent, _ := Exponent(code)
+		for _, amount := range []int64{0, 1, -1, 9, 10, 99, 100, 101, 123456789, -987654321, math.MaxInt64, math.MinInt64 + 1} {
+			text := FormatDecimal(amount, exponent)
+			if amount < 0 {
+				text = "-" + text
+			}
+			got, err := Parse(text, code)
+			if err != nil || got.Amount != amount {
+				t.Errorf("Parse(%q, %s) = %v, %v; want %d", text, code, got, err, amount)
+			}
+		}
+	}
+}
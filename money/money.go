@@ -0,0 +1,142 @@
+// Package money represents amounts as integer minor units of an ISO 4217
+// currency (paise for INR, cents for USD, whole yen for JPY).
+// There are no floats anywhere: every operation is exact or fails.
+package money
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"splitwise-api/algorithms"
+)
+
+var (
+	ErrOverflow         = errors.New("amount overflows int64")
+	ErrCurrencyMismatch = errors.New("cannot combine amounts in different currencies")
+)
+
+// Money is an amount in the minor unit of Currency.
+// Example: Money{Amount: 10050, Currency: "INR"} is ₹100.50.
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// New returns Money after checking the currency is supported.
+func New(amount int64, currency string) (Money, error) {
+	code, ok := NormalizeCurrency(currency)
+	if !ok {
+		return Money{}, ErrUnknownCurrency
+	}
+	return Money{Amount: amount, Currency: code}, nil
+}
+
+// Add returns m + other. Both must share a currency.
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	sum, err := AddInt64(m.Amount, other.Amount)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: sum, Currency: m.Currency}, nil
+}
+
+// Sub returns m − other. Both must share a currency.
+func (m Money) Sub(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	if other.Amount == math.MinInt64 {
+		return Money{}, ErrOverflow
+	}
+	diff, err := AddInt64(m.Amount, -other.Amount)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: diff, Currency: m.Currency}, nil
+}
+
+// Allocate splits m into parts proportional to weights. The parts always sum
+// to exactly m (largest remainder method, ties to the earlier index).
+func (m Money) Allocate(weights []int64) ([]Money, error) {
+	if m.Amount < 0 {
+		return nil, errors.New("cannot allocate a negative amount")
+	}
+	amounts, err := algorithms.Allocate(m.Amount, weights)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := make([]Money, len(amounts))
+	for i, a := range amounts {
+		parts[i] = Money{Amount: a, Currency: m.Currency}
+	}
+	return parts, nil
+}
+
+// Convert expresses m in currency `to` using the exact rate num/den
+// (1 major unit of m.Currency = num/den major units of `to`), rounding half
+// away from zero to the nearest minor unit of `to`. Differences in minor-unit
+// exponents (INR 2 → JPY 0) are accounted for.
+func (m Money) Convert(to string, num, den int64) (Money, error) {
+	if num <= 0 || den <= 0 {
+		return Money{}, ErrInvalidRate
+	}
+	fromExp, err := Exponent(m.Currency)
+	if err != nil {
+		return Money{}, err
+	}
+	toExp, err := Exponent(to)
+	if err != nil {
+		return Money{}, err
+	}
+
+	// result = amount × num × 10^toExp / (den × 10^fromExp)
+	numerator := new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(num))
+	numerator.Mul(numerator, pow10(toExp))
+	denominator := new(big.Int).Mul(big.NewInt(den), pow10(fromExp))
+
+	quotient, remainder := new(big.Int).QuoRem(numerator, denominator, new(big.Int))
+
+	// Round half away from zero: |2 × remainder| ≥ denominator
+	twice := new(big.Int).Abs(remainder)
+	twice.Lsh(twice, 1)
+	if twice.Cmp(denominator) >= 0 {
+		if numerator.Sign() < 0 {
+			quotient.Sub(quotient, big.NewInt(1))
+		} else {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	}
+
+	if !quotient.IsInt64() {
+		return Money{}, ErrOverflow
+	}
+	return Money{Amount: quotient.Int64(), Currency: to}, nil
+}
+
+// AddInt64 returns a + b, or ErrOverflow if the result does not fit in int64.
+func AddInt64(a, b int64) (int64, error) {
+	if (b > 0 && a > math.MaxInt64-b) || (b < 0 && a < math.MinInt64-b) {
+		return 0, ErrOverflow
+	}
+	return a + b, nil
+}
+
+// MulInt64 returns a × b, or ErrOverflow if the result does not fit in int64.
+func MulInt64(a, b int64) (int64, error) {
+	if a == 0 || b == 0 {
+		return 0, nil
+	}
+	product := a * b
+	if product/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, ErrOverflow
+	}
+	return product, nil
+}
+
+func pow10(exp int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil)
+}
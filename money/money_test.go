@@ -0,0 +1,199 @@
+package money
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     Money
+		to       string
+		num, den int64
+		want     int64
+	}{
+		// Exponent 2 → 2
+		{"USD to INR", Money{1001, "USD"}, "INR", 333, 4, 83333}, // 83333.25
+		{"rounds half up", Money{3, "USD"}, "INR", 1, 2, 2},      // 1.5
+		{"rounds half away from zero when negative", Money{-3, "USD"}, "INR", 1, 2, -2},
+		{"rounds below half down", Money{1, "USD"}, "INR", 1, 3, 0},
+		{"rounds below half toward zero when negative", Money{-1, "USD"}, "INR", 1, 3, 0},
+		{"inverse rate", Money{10000, "INR"}, "USD", 4, 333, 120}, // 120.12…
+
+		// Exponent 2 → 0 and back
+		{"USD to JPY", Money{1001, "USD"}, "JPY", 150, 1, 1502}, // 1501.5
+		{"negative USD to JPY", Money{-1001, "USD"}, "JPY", 150, 1, -1502},
+		{"JPY to USD", Money{100, "JPY"}, "USD", 1, 150, 67}, // 66.67 cents
+
+		// Exponent 2 → 3 and back
+		{"INR to KWD", Money{10000, "INR"}, "KWD", 37, 10000, 370},
+		{"KWD to INR", Money{1250, "KWD"}, "INR", 270, 1, 33750},
+		{"KWD to JPY", Money{1, "KWD"}, "JPY", 500, 1, 1}, // 0.5 yen
+
+		{"zero", Money{0, "USD"}, "INR", 333, 4, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.from.Convert(tt.to, tt.num, tt.den)
+			if err != nil {
+				t.Fatalf("Convert: %v", err)
+			}
+			if got.Amount != tt.want || got.Currency != tt.to {
+				t.Errorf("%v.Convert(%s, %d/%d) = %v, want %d %s", tt.from, tt.to, tt.num, tt.den, got, tt.want, tt.to)
+			}
+		})
+	}
+}
+
+func TestConvertErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     Money
+		to       string
+		num, den int64
+		want     error
+	}{
+		{"zero rate", Money{100, "USD"}, "INR", 0, 1, ErrInvalidRate},
+		{"negative rate", Money{100, "USD"}, "INR", -1, 1, ErrInvalidRate},
+		{"zero denominator", Money{100, "USD"}, "INR", 1, 0, ErrInvalidRate},
+		{"unknown source currency", Money{100, "XXX"}, "INR", 1, 1, ErrUnknownCurrency},
+		{"unknown target currency", Money{100, "USD"}, "XXX", 1, 1, ErrUnknownCurrency},
+		{"overflow", Money{math.MaxInt64, "USD"}, "INR", 2, 1, ErrOverflow},
+		{"overflow through exponents", Money{math.MaxInt64 / 10, "JPY"}, "KWD", 1, 1, ErrOverflow},
+		{"negative overflow", Money{math.MinInt64, "USD"}, "INR", 2, 1, ErrOverflow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.from.Convert(tt.to, tt.num, tt.den)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("%v.Convert(%s, %d/%d) = %v, %v; want error %v", tt.from, tt.to, tt.num, tt.den, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   Money
+		weights []int64
+		want    []int64
+	}{
+		{"thirds", Money{10000, "INR"}, []int64{1, 1, 1}, []int64{3334, 3333, 3333}},
+		{"converted total over splits", Money{83333, "INR"}, []int64{501, 500}, []int64{41708, 41625}},
+		{"JPY has no minor unit", Money{1000, "JPY"}, []int64{1, 1, 1}, []int64{334, 333, 333}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts, err := tt.total.Allocate(tt.weights)
+			if err != nil {
+				t.Fatalf("Allocate: %v", err)
+			}
+			var sum int64
+			for i, p := range parts {
+				if p.Amount != tt.want[i] || p.Currency != tt.total.Currency {
+					t.Errorf("part %d = %v, want %d %s", i, p, tt.want[i], tt.total.Currency)
+				}
+				sum += p.Amount
+			}
+			if sum != tt.total.Amount {
+				t.Errorf("parts sum to %d, want %d", sum, tt.total.Amount)
+			}
+		})
+	}
+
+	if _, err := (Money{-100, "INR"}).Allocate([]int64{1, 1}); err == nil {
+		t.Error("allocating a negative amount succeeded")
+	}
+}
+
+func TestAddSub(t *testing.T) {
+	sum, err := Money{10050, "INR"}.Add(Money{-50, "INR"})
+	if err != nil || sum != (Money{10000, "INR"}) {
+		t.Errorf("Add = %v, %v; want 10000 INR", sum, err)
+	}
+	diff, err := Money{100, "USD"}.Sub(Money{250, "USD"})
+	if err != nil || diff != (Money{-150, "USD"}) {
+		t.Errorf("Sub = %v, %v; want -150 USD", diff, err)
+	}
+
+	if _, err := (Money{1, "INR"}).Add(Money{1, "USD"}); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Add across currencies: %v, want ErrCurrencyMismatch", err)
+	}
+	if _, err := (Money{1, "INR"}).Sub(Money{1, "USD"}); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Sub across currencies: %v, want ErrCurrencyMismatch", err)
+	}
+	if _, err := (Money{math.MaxInt64, "INR"}).Add(Money{1, "INR"}); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Add past MaxInt64: %v, want ErrOverflow", err)
+	}
+	if _, err := (Money{0, "INR"}).Sub(Money{math.MinInt64, "INR"}); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Sub of MinInt64: %v, want ErrOverflow", err)
+	}
+	if _, err := (Money{math.MinInt64, "INR"}).Sub(Money{1, "INR"}); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Sub past MinInt64: %v, want ErrOverflow", err)
+	}
+}
+
+func TestAddInt64(t *testing.T) {
+	tests := []struct {
+		a, b     int64
+		want     int64
+		overflow bool
+	}{
+		{1, 2, 3, false},
+		{-5, 3, -2, false},
+		{math.MaxInt64, 0, math.MaxInt64, false},
+		{math.MaxInt64 - 1, 1, math.MaxInt64, false},
+		{math.MaxInt64, 1, 0, true},
+		{math.MinInt64 + 1, -1, math.MinInt64, false},
+		{math.MinInt64, -1, 0, true},
+		{math.MaxInt64, math.MinInt64, -1, false},
+	}
+	for _, tt := range tests {
+		got, err := AddInt64(tt.a, tt.b)
+		if tt.overflow {
+			if !errors.Is(err, ErrOverflow) {
+				t.Errorf("AddInt64(%d, %d) = %d, %v; want ErrOverflow", tt.a, tt.b, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("AddInt64(%d, %d) = %d, %v; want %d", tt.a, tt.b, got, err, tt.want)
+		}
+	}
+}
+
+func TestMulInt64(t *testing.T) {
+	tests := []struct {
+		a, b     int64
+		want     int64
+		overflow bool
+	}{
+		{6, 7, 42, false},
+		{-6, 7, -42, false},
+		{0, math.MaxInt64, 0, false},
+		{math.MinInt64, 0, 0, false},
+		{math.MaxInt64, 1, math.MaxInt64, false},
+		{math.MaxInt64, -1, -math.MaxInt64, false},
+		{math.MinInt64, 1, math.MinInt64, false},
+		{math.MaxInt64/100 + 1, 100, 0, true},
+		{math.MaxInt64, 2, 0, true},
+		{math.MinInt64, -1, 0, true},
+		{-1, math.MinInt64, 0, true},
+		{1 << 32, 1 << 31, 0, true},
+	}
+	for _, tt := range tests {
+		got, err := MulInt64(tt.a, tt.b)
+		if tt.overflow {
+			if !errors.Is(err, ErrOverflow) {
+				t.Errorf("MulInt64(%d, %d) = %d, %v; want ErrOverflow", tt.a, tt.b, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("MulInt64(%d, %d) = %d, %v; want %d", tt.a, tt.b, got, err, tt.want)
+		}
+	}
+}
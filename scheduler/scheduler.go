@@ -0,0 +1,46 @@
+package scheduler
+
+import (
+	"log"
+	"time"
+)
+
+// Job is a task run periodically in the background.
+// Run receives the current time and should pick up everything due by then,
+// including work missed while the server was down.
+type Job struct {
+	Name  string
+	Every time.Duration
+	Run   func(now time.Time) error
+}
+
+// Start runs each job once right away and then every job.Every, each in its
+// own goroutine. Errors (and panics) are logged and the job simply runs again
+// on its next tick.
+func Start(jobs ...Job) {
+	for _, job := range jobs {
+		go loop(job)
+	}
+}
+
+func loop(job Job) {
+	ticker := time.NewTicker(job.Every)
+	defer ticker.Stop()
+
+	for {
+		runOnce(job)
+		<-ticker.C
+	}
+}
+
+func runOnce(job Job) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("scheduler: job %q panicked: %v", job.Name, r)
+		}
+	}()
+
+	if err := job.Run(time.Now()); err != nil {
+		log.Printf("scheduler: job %q failed: %v", job.Name, err)
+	}
+}
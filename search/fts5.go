@@ -0,0 +1,74 @@
+package search
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// fts5 keeps documents in an SQLite FTS5 virtual table whose rowid is the
+// expense ID. group_id is stored but not tokenized, to scope queries.
+type fts5 struct{}
+
+// Column weights for bm25, in table order: a word in the description counts
+// far more than the same word buried in a comment.
+const fts5Rank = "bm25(expense_search, 10.0, 4.0, 3.0, 4.0, 2.0)"
+
+func newFTS5(db *gorm.DB) (*fts5, error) {
+	// unicode61 with remove_diacritics folds "Café" and "cafe" together
+	err := db.Exec(`
+		CREATE VIRTUAL TABLE IF NOT EXISTS expense_search USING fts5(
+			description, notes, category, items, comments,
+			group_id UNINDEXED,
+			tokenize = 'unicode61 remove_diacritics 2'
+		)`).Error
+	if err != nil {
+		return nil, err
+	}
+	return &fts5{}, nil
+}
+
+func (f fts5) put(tx *gorm.DB, doc Document) error {
+	if err := f.remove(tx, doc.ExpenseID); err != nil {
+		return err
+	}
+	return tx.Exec(`
+		INSERT INTO expense_search (rowid, description, notes, category, items, comments, group_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		doc.ExpenseID, doc.Description, doc.Notes, doc.Category, doc.Items, doc.Comments, doc.GroupID).Error
+}
+
+func (fts5) remove(tx *gorm.DB, expenseID uint) error {
+	return tx.Exec("DELETE FROM expense_search WHERE rowid = ?", expenseID).Error
+}
+
+func (fts5) search(db *gorm.DB, groupIDs []uint, terms []string, limit int) ([]Hit, error) {
+	hits := []Hit{}
+	err := db.Raw(`
+		SELECT rowid AS expense_id, group_id,
+		       snippet(expense_search, -1, '[', ']', '…', 12) AS snippet
+		FROM expense_search
+		WHERE expense_search MATCH ? AND group_id IN ?
+		ORDER BY `+fts5Rank+`, rowid DESC
+		LIMIT ?`,
+		matchExpression(terms), groupIDs, limit).Scan(&hits).Error
+	return hits, err
+}
+
+func (fts5) empty(db *gorm.DB) bool {
+	var n int64
+	db.Raw("SELECT count(*) FROM expense_search").Scan(&n)
+	return n == 0
+}
+
+// matchExpression ORs the quoted terms together; the last one is also a
+// prefix. Terms holds only letters and digits, so quoting is all the
+// escaping FTS5 needs.
+func matchExpression(terms []string) string {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = `"` + term + `"`
+	}
+	quoted[len(quoted)-1] += "*"
+	return strings.Join(quoted, " OR ")
+}
@@ -0,0 +1,170 @@
+// Package search is the full-text index over expenses: their description,
+// notes, category, receipt items and comments. On SQLite it is an FTS5
+// table ranked by bm25; on databases without FTS5 it falls back to a plain
+// table matched with LIKE.
+//
+// The index is written through the same *gorm.DB transaction as the change
+// it reflects, so a rolled-back edit never leaves a stale entry behind.
+package search
+
+import (
+	"errors"
+	"log"
+	"splitwise-api/models"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// Document is what gets indexed for one expense.
+type Document struct {
+	ExpenseID   uint
+	GroupID     uint
+	Description string
+	Notes       string
+	Category    string
+	Items       string // receipt item descriptions, one per line
+	Comments    string // comment bodies, one per line
+}
+
+// Hit is one matching expense, best match first. Snippet is a short excerpt
+// around the match with the matched terms in [brackets].
+type Hit struct {
+	ExpenseID uint   `json:"expense_id"`
+	GroupID   uint   `json:"group_id"`
+	Snippet   string `json:"snippet"`
+}
+
+// backend stores and queries documents.
+type backend interface {
+	put(tx *gorm.DB, doc Document) error
+	remove(tx *gorm.DB, expenseID uint) error
+	search(db *gorm.DB, groupIDs []uint, terms []string, limit int) ([]Hit, error)
+	empty(db *gorm.DB) bool
+}
+
+var index backend
+
+// Setup picks FTS5 when the database supports it, otherwise the LIKE
+// fallback, and indexes existing expenses the first time it runs.
+func Setup(db *gorm.DB) {
+	if fts, err := newFTS5(db); err == nil {
+		index = fts
+	} else {
+		log.Printf("search: FTS5 unavailable (%v), using LIKE fallback", err)
+		fallback, err := newLike(db)
+		if err != nil {
+			log.Printf("search: index disabled: %v", err)
+			return
+		}
+		index = fallback
+	}
+
+	if index.empty(db) {
+		if err := reindexAll(db); err != nil {
+			log.Printf("search: initial indexing failed: %v", err)
+		}
+	}
+}
+
+// IndexExpense (re)writes the document for an expense from its current
+// rows. An expense that no longer exists (or is soft-deleted) is removed.
+func IndexExpense(tx *gorm.DB, expenseID uint) error {
+	if index == nil {
+		return nil
+	}
+
+	var expense models.Expense
+	err := tx.Session(&gorm.Session{NewDB: true}).Preload("Items").First(&expense, expenseID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return index.remove(tx, expenseID)
+	}
+	if err != nil {
+		return err
+	}
+
+	doc, err := document(tx, expense)
+	if err != nil {
+		return err
+	}
+	return index.put(tx, doc)
+}
+
+// RemoveExpense drops an expense from the index.
+func RemoveExpense(tx *gorm.DB, expenseID uint) error {
+	if index == nil {
+		return nil
+	}
+	return index.remove(tx, expenseID)
+}
+
+// Search returns up to limit expenses in groupIDs matching query. Any of the
+// words may match, and expenses matching more of them (or matching in the
+// description rather than a comment) rank higher. The last word also
+// matches as a prefix, so "goa ub" finds "Uber in Goa" while typing.
+func Search(db *gorm.DB, groupIDs []uint, query string, limit int) ([]Hit, error) {
+	terms := Terms(query)
+	if index == nil || len(terms) == 0 || len(groupIDs) == 0 {
+		return []Hit{}, nil
+	}
+	return index.search(db, groupIDs, terms, limit)
+}
+
+// Terms splits a query into lowercase words. Punctuation is dropped, so
+// query syntax such as quotes or "*" can never reach the database.
+func Terms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// document gathers the searchable text of an expense.
+func document(tx *gorm.DB, expense models.Expense) (Document, error) {
+	db := tx.Session(&gorm.Session{NewDB: true})
+	doc := Document{
+		ExpenseID:   expense.ID,
+		GroupID:     expense.GroupID,
+		Description: expense.Description,
+		Notes:       expense.Notes,
+	}
+
+	if expense.CategoryID != nil {
+		var category models.Category
+		if err := db.Unscoped().First(&category, *expense.CategoryID).Error; err == nil {
+			doc.Category = category.Name
+		}
+	}
+
+	items := make([]string, 0, len(expense.Items))
+	for _, item := range expense.Items {
+		items = append(items, item.Description)
+	}
+	doc.Items = strings.Join(items, "\n")
+
+	var comments []string
+	if err := db.Model(&models.ExpenseComment{}).Where("expense_id = ?", expense.ID).
+		Order("id").Pluck("body", &comments).Error; err != nil {
+		return doc, err
+	}
+	doc.Comments = strings.Join(comments, "\n")
+
+	return doc, nil
+}
+
+// reindexAll indexes every expense, e.g. on the first start after upgrading.
+func reindexAll(db *gorm.DB) error {
+	var ids []uint
+	if err := db.Model(&models.Expense{}).Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := IndexExpense(db, id); err != nil {
+			return err
+		}
+	}
+	if len(ids) > 0 {
+		log.Printf("search: indexed %d expenses", len(ids))
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// searchDocument is the fallback index table. Content holds every field,
+// lowercased, for LIKE matching; the fields themselves are kept for snippets.
+type searchDocument struct {
+	ExpenseID   uint `gorm:"primaryKey;autoIncrement:false"`
+	GroupID     uint `gorm:"not null;index"`
+	Description string
+	Notes       string
+	Category    string
+	Items       string
+	Comments    string
+	Content     string
+}
+
+// like is the index for databases without FTS5. It ranks by how many of the
+// query words appear, then by whether they appear in the description.
+type like struct{}
+
+func newLike(db *gorm.DB) (*like, error) {
+	if err := db.AutoMigrate(&searchDocument{}); err != nil {
+		return nil, err
+	}
+	return &like{}, nil
+}
+
+func (like) put(tx *gorm.DB, doc Document) error {
+	row := searchDocument{
+		ExpenseID:   doc.ExpenseID,
+		GroupID:     doc.GroupID,
+		Description: doc.Description,
+		Notes:       doc.Notes,
+		Category:    doc.Category,
+		Items:       doc.Items,
+		Comments:    doc.Comments,
+		Content: strings.ToLower(strings.Join(
+			[]string{doc.Description, doc.Notes, doc.Category, doc.Items, doc.Comments}, "\n")),
+	}
+	return tx.Save(&row).Error
+}
+
+func (like) remove(tx *gorm.DB, expenseID uint) error {
+	return tx.Where("expense_id = ?", expenseID).Delete(&searchDocument{}).Error
+}
+
+func (like) search(db *gorm.DB, groupIDs []uint, terms []string, limit int) ([]Hit, error) {
+	var (
+		anyTerm  []string
+		score    []string
+		patterns []interface{}
+	)
+	for _, term := range terms {
+		pattern := "%" + term + "%" // terms are letters and digits only: no wildcards to escape
+		patterns = append(patterns, pattern)
+		anyTerm = append(anyTerm, "content LIKE ?")
+		score = append(score, "(CASE WHEN content LIKE ? THEN 1 ELSE 0 END)")
+	}
+	descriptionMatch := strings.ReplaceAll(strings.Join(anyTerm, " OR "), "content", "LOWER(description)")
+
+	// Order() drops the vars of a plain gorm.Expr, so the ranking has to be
+	// passed as an OrderBy clause.
+	var rows []searchDocument
+	err := db.Where("group_id IN ?", groupIDs).
+		Where(strings.Join(anyTerm, " OR "), patterns...).
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:                fmt.Sprintf("(%s) DESC, CASE WHEN %s THEN 0 ELSE 1 END, expense_id DESC", strings.Join(score, " + "), descriptionMatch),
+			Vars:               append(append([]interface{}{}, patterns...), patterns...),
+			WithoutParentheses: true,
+		}}).
+		Limit(limit).
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]Hit, 0, len(rows))
+	for _, row := range rows {
+		hits = append(hits, Hit{
+			ExpenseID: row.ExpenseID,
+			GroupID:   row.GroupID,
+			Snippet:   excerpt(terms, row.Description, row.Notes, row.Category, row.Items, row.Comments),
+		})
+	}
+	return hits, nil
+}
+
+func (like) empty(db *gorm.DB) bool {
+	var n int64
+	db.Model(&searchDocument{}).Count(&n)
+	return n == 0
+}
+
+// excerpt mimics FTS5's snippet(): up to 12 words of the first field
+// containing a term, with matching words in [brackets].
+func excerpt(terms []string, fields ...string) string {
+	const window = 12
+	for _, field := range fields {
+		words := strings.Fields(field)
+		for i, word := range words {
+			if !matchesAny(word, terms) {
+				continue
+			}
+			start := max(0, i-window/2)
+			end := min(len(words), start+window)
+			marked := make([]string, 0, end-start)
+			for _, w := range words[start:end] {
+				if matchesAny(w, terms) {
+					w = "[" + w + "]"
+				}
+				marked = append(marked, w)
+			}
+			snippet := strings.Join(marked, " ")
+			if start > 0 {
+				snippet = "…" + snippet
+			}
+			if end < len(words) {
+				snippet += "…"
+			}
+			return snippet
+		}
+	}
+	return ""
+}
+
+func matchesAny(word string, terms []string) bool {
+	word = strings.ToLower(word)
+	for _, term := range terms {
+		if strings.Contains(word, term) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestLikeRanking(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := newLike(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	docs := []Document{
+		{ExpenseID: 1, GroupID: 1, Description: "Uber to the airport"},
+		{ExpenseID: 2, GroupID: 1, Description: "Dinner", Notes: "paid the uber driver in goa"},
+		{ExpenseID: 3, GroupID: 1, Description: "Hotel", Notes: "goa trip"},
+		{ExpenseID: 4, GroupID: 1, Description: "Goa beach shacks"},
+		{ExpenseID: 5, GroupID: 2, Description: "Uber in Goa"}, // another group
+		{ExpenseID: 6, GroupID: 1, Description: "Groceries"},
+	}
+	for _, doc := range docs {
+		if err := l.put(db, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	hits, err := l.search(db, []uint{1}, []string{"uber", "goa"}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []uint
+	for _, hit := range hits {
+		got = append(got, hit.ExpenseID)
+	}
+	// Both words first, then a description match, then newest first.
+	want := []uint{2, 4, 1, 3}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("search(uber goa) = %v, want %v", got, want)
+	}
+	if want := "paid the [uber] driver in [goa]"; len(hits) > 0 && hits[0].Snippet != want {
+		t.Errorf("snippet = %q, want %q", hits[0].Snippet, want)
+	}
+}
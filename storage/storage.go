@@ -0,0 +1,23 @@
+// Package storage keeps uploaded files (receipt images, PDFs) outside the
+// database. Handlers talk to the Store interface, so the local filesystem
+// implementation can be swapped for an object store without touching them.
+package storage
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrNotFound is returned by Open for a key that does not exist.
+var ErrNotFound = errors.New("file not found")
+
+// Store saves and serves blobs by key. Keys are slash-separated paths made by
+// the application, such as "expenses/12/3f9c…"; never user input.
+type Store interface {
+	// Put writes r under key, replacing any existing blob.
+	Put(key string, r io.Reader) error
+	// Open returns the blob stored under key. The caller closes it.
+	Open(key string) (io.ReadCloser, error)
+	// Delete removes the blob under key. Deleting a missing key is not an error.
+	Delete(key string) error
+}